@@ -13,6 +13,22 @@ type Config struct {
 	Version string
 }
 
+// providerMeta is the meta passed to the resources and data sources of a
+// provider instance: the API client, and what is cached for the lifetime of
+// the provider instance.
+type providerMeta struct {
+	*cloudscale.Client
+
+	// slugs holds the slugs of flavors, images and zones, by API path.
+	slugs memo[[]string]
+	// poolLoadBalancers holds the load balancer of each pool, by pool UUID.
+	poolLoadBalancers memo[string]
+}
+
+func newProviderMeta(client *cloudscale.Client) *providerMeta {
+	return &providerMeta{Client: client}
+}
+
 func (c *Config) Client() (*cloudscale.Client, error) {
 	tc := oauth2.NewClient(context.Background(), oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: c.Token},
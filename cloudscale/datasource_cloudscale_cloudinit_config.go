@@ -0,0 +1,239 @@
+package cloudscale
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+const (
+	userDataHandlingPassThrough       = "pass-through"
+	userDataHandlingExtendCloudConfig = "extend-cloud-config"
+
+	cloudConfigContentType = "text/cloud-config"
+	cloudConfigHeader      = "#cloud-config"
+)
+
+var cloudInitPartContentTypes = []string{
+	cloudConfigContentType,
+	"text/x-shellscript",
+	"text/cloud-boothook",
+	"text/part-handler",
+	"text/x-include-url",
+	"text/jinja2",
+}
+
+// dataSourceCloudscaleCloudInitConfig renders user data for cloudscale_server
+// from several parts. Unlike the other data sources it never calls the API, so
+// it can be evaluated (and checked) offline.
+func dataSourceCloudscaleCloudInitConfig() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceCloudscaleCloudInitConfigRead,
+		Schema:      getCloudInitConfigSchema(),
+	}
+}
+
+func getCloudInitConfigSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"part": {
+			Type:     schema.TypeList,
+			Required: true,
+			MinItems: 1,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"content_type": {
+						Type:         schema.TypeString,
+						Optional:     true,
+						Default:      cloudConfigContentType,
+						ValidateFunc: validation.StringInSlice(cloudInitPartContentTypes, false),
+					},
+					"content": {
+						Type:     schema.TypeString,
+						Required: true,
+					},
+					"filename": {
+						Type:     schema.TypeString,
+						Optional: true,
+					},
+					"merge_type": {
+						Type:     schema.TypeString,
+						Optional: true,
+					},
+				},
+			},
+		},
+		"gzip": {
+			Type:     schema.TypeBool,
+			Optional: true,
+			Default:  false,
+		},
+		"base64_encode": {
+			Type:     schema.TypeBool,
+			Optional: true,
+			Default:  false,
+		},
+		"boundary": {
+			Type:     schema.TypeString,
+			Optional: true,
+			Default:  "MIMEBOUNDARY",
+		},
+		"user_data_handling": {
+			Type:     schema.TypeString,
+			Optional: true,
+			Default:  userDataHandlingPassThrough,
+			ValidateFunc: validation.StringInSlice([]string{
+				userDataHandlingPassThrough,
+				userDataHandlingExtendCloudConfig,
+			}, false),
+		},
+		"rendered": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+	}
+}
+
+type cloudInitPart struct {
+	ContentType string
+	Content     string
+	Filename    string
+	MergeType   string
+}
+
+func dataSourceCloudscaleCloudInitConfigRead(_ context.Context, d *schema.ResourceData, _ any) diag.Diagnostics {
+	rawParts := d.Get("part").([]any)
+	parts := make([]cloudInitPart, len(rawParts))
+	for i, rawPart := range rawParts {
+		p := rawPart.(map[string]any)
+		parts[i] = cloudInitPart{
+			ContentType: p["content_type"].(string),
+			Content:     p["content"].(string),
+			Filename:    p["filename"].(string),
+			MergeType:   p["merge_type"].(string),
+		}
+	}
+
+	rendered, err := renderCloudInitConfig(
+		parts,
+		d.Get("boundary").(string),
+		d.Get("gzip").(bool),
+		d.Get("base64_encode").(bool),
+		d.Get("user_data_handling").(string),
+	)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	hash := sha256.Sum256([]byte(rendered))
+	d.SetId(hex.EncodeToString(hash[:]))
+	if err := d.Set("rendered", rendered); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+// renderCloudInitConfig assembles the parts into user data that the image can
+// consume given its user_data_handling:
+//   - pass-through:        the parts are sent as a MIME multipart document,
+//     optionally gzipped and base64 encoded.
+//   - extend-cloud-config: cloudscale merges the user data into its own
+//     cloud-config, so it has to be exactly one plain cloud-config document.
+func renderCloudInitConfig(parts []cloudInitPart, boundary string, gzipped bool, base64Encoded bool, userDataHandling string) (string, error) {
+	if gzipped && !base64Encoded {
+		return "", fmt.Errorf("gzip requires base64_encode: user data must be valid UTF-8")
+	}
+
+	var rendered string
+	switch userDataHandling {
+	case userDataHandlingExtendCloudConfig:
+		if len(parts) != 1 || parts[0].ContentType != cloudConfigContentType {
+			return "", fmt.Errorf("user_data_handling %q requires exactly one part of type %q", userDataHandling, cloudConfigContentType)
+		}
+		if gzipped || base64Encoded {
+			return "", fmt.Errorf("user_data_handling %q does not support gzip or base64_encode", userDataHandling)
+		}
+		if !strings.HasPrefix(parts[0].Content, cloudConfigHeader) {
+			return "", fmt.Errorf("user_data_handling %q requires the part to start with %q", userDataHandling, cloudConfigHeader)
+		}
+		rendered = parts[0].Content
+	case userDataHandlingPassThrough:
+		document, err := renderCloudInitMultipart(parts, boundary)
+		if err != nil {
+			return "", err
+		}
+		if gzipped {
+			document, err = gzipCloudInitDocument(document)
+			if err != nil {
+				return "", err
+			}
+		}
+		if base64Encoded {
+			document = []byte(base64.StdEncoding.EncodeToString(document))
+		}
+		rendered = string(document)
+	default:
+		return "", fmt.Errorf("unknown user_data_handling %q", userDataHandling)
+	}
+
+	return rendered, nil
+}
+
+func renderCloudInitMultipart(parts []cloudInitPart, boundary string) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	if err := writer.SetBoundary(boundary); err != nil {
+		return nil, fmt.Errorf("invalid boundary %q: %s", boundary, err)
+	}
+
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=\"%s\"\r\n", boundary)
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n\r\n")
+
+	for _, part := range parts {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", part.ContentType+"; charset=\"utf-8\"")
+		header.Set("Content-Transfer-Encoding", "7bit")
+		header.Set("MIME-Version", "1.0")
+		if part.Filename != "" {
+			header.Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", part.Filename))
+		}
+		if part.MergeType != "" {
+			header.Set("X-Merge-Type", part.MergeType)
+		}
+
+		w, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, fmt.Errorf("error writing user data part: %s", err)
+		}
+		if _, err := w.Write([]byte(part.Content)); err != nil {
+			return nil, fmt.Errorf("error writing user data part: %s", err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("error writing user data: %s", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func gzipCloudInitDocument(document []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(document); err != nil {
+		return nil, fmt.Errorf("error compressing user data: %s", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("error compressing user data: %s", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,166 @@
+package cloudscale
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+const testCloudConfig = "#cloud-config\npackages:\n  - postgresql\n"
+
+func testCloudInitParts() []cloudInitPart {
+	return []cloudInitPart{
+		{ContentType: cloudConfigContentType, Content: testCloudConfig, MergeType: "list(append)+dict(recurse_array)"},
+		{ContentType: "text/x-shellscript", Content: "#!/bin/sh\necho hello\n", Filename: "hello.sh"},
+	}
+}
+
+// readCloudInitMultipart parses a rendered document the way cloud-init does and
+// returns the parts it contains.
+func readCloudInitMultipart(t *testing.T, document string) []*multipart.Part {
+	t.Helper()
+
+	msg, err := mail.ReadMessage(strings.NewReader(document))
+	if err != nil {
+		t.Fatalf("reading message: %s", err)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("parsing content type: %s", err)
+	}
+	if mediaType != "multipart/mixed" {
+		t.Fatalf("media type = %q, want multipart/mixed", mediaType)
+	}
+
+	var parts []*multipart.Part
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return parts
+		}
+		if err != nil {
+			t.Fatalf("reading part: %s", err)
+		}
+		parts = append(parts, part)
+	}
+}
+
+func TestRenderCloudInitConfig_Multipart(t *testing.T) {
+	rendered, err := renderCloudInitConfig(testCloudInitParts(), "BOUNDARY", false, false, userDataHandlingPassThrough)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	parts := readCloudInitMultipart(t, rendered)
+	if len(parts) != 2 {
+		t.Fatalf("got %d parts, want 2", len(parts))
+	}
+	if got := parts[0].Header.Get("X-Merge-Type"); got != "list(append)+dict(recurse_array)" {
+		t.Errorf("X-Merge-Type = %q", got)
+	}
+	if got := parts[1].FileName(); got != "hello.sh" {
+		t.Errorf("filename = %q, want hello.sh", got)
+	}
+	if got := parts[1].Header.Get("Content-Type"); !strings.HasPrefix(got, "text/x-shellscript") {
+		t.Errorf("Content-Type = %q", got)
+	}
+}
+
+func TestRenderCloudInitConfig_GzipBase64(t *testing.T) {
+	rendered, err := renderCloudInitConfig(testCloudInitParts(), "BOUNDARY", true, true, userDataHandlingPassThrough)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	compressed, err := base64.StdEncoding.DecodeString(rendered)
+	if err != nil {
+		t.Fatalf("decoding base64: %s", err)
+	}
+	r, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatalf("opening gzip: %s", err)
+	}
+	document, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("reading gzip: %s", err)
+	}
+	if parts := readCloudInitMultipart(t, string(document)); len(parts) != 2 {
+		t.Errorf("got %d parts, want 2", len(parts))
+	}
+}
+
+func TestRenderCloudInitConfig_GzipRequiresBase64(t *testing.T) {
+	if _, err := renderCloudInitConfig(testCloudInitParts(), "BOUNDARY", true, false, userDataHandlingPassThrough); err == nil {
+		t.Error("expected an error for gzip without base64_encode")
+	}
+}
+
+func TestRenderCloudInitConfig_ExtendCloudConfig(t *testing.T) {
+	t.Run("renders a single cloud-config as is", func(t *testing.T) {
+		parts := []cloudInitPart{{ContentType: cloudConfigContentType, Content: testCloudConfig}}
+		rendered, err := renderCloudInitConfig(parts, "BOUNDARY", false, false, userDataHandlingExtendCloudConfig)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if rendered != testCloudConfig {
+			t.Errorf("rendered = %q, want %q", rendered, testCloudConfig)
+		}
+	})
+
+	t.Run("rejects several parts", func(t *testing.T) {
+		if _, err := renderCloudInitConfig(testCloudInitParts(), "BOUNDARY", false, false, userDataHandlingExtendCloudConfig); err == nil {
+			t.Error("expected an error for several parts")
+		}
+	})
+
+	t.Run("rejects shell scripts", func(t *testing.T) {
+		parts := []cloudInitPart{{ContentType: "text/x-shellscript", Content: "#!/bin/sh\n"}}
+		if _, err := renderCloudInitConfig(parts, "BOUNDARY", false, false, userDataHandlingExtendCloudConfig); err == nil {
+			t.Error("expected an error for a shell script")
+		}
+	})
+
+	t.Run("rejects encodings", func(t *testing.T) {
+		parts := []cloudInitPart{{ContentType: cloudConfigContentType, Content: testCloudConfig}}
+		if _, err := renderCloudInitConfig(parts, "BOUNDARY", true, true, userDataHandlingExtendCloudConfig); err == nil {
+			t.Error("expected an error for gzip")
+		}
+	})
+
+	t.Run("rejects a missing cloud-config header", func(t *testing.T) {
+		parts := []cloudInitPart{{ContentType: cloudConfigContentType, Content: "packages: []\n"}}
+		if _, err := renderCloudInitConfig(parts, "BOUNDARY", false, false, userDataHandlingExtendCloudConfig); err == nil {
+			t.Error("expected an error for a missing #cloud-config header")
+		}
+	})
+}
+
+func TestDataSourceCloudscaleCloudInitConfigRead(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, getCloudInitConfigSchema(), map[string]any{
+		"user_data_handling": userDataHandlingExtendCloudConfig,
+		"part": []any{
+			map[string]any{"content": testCloudConfig},
+		},
+	})
+
+	// meta is intentionally nil: rendering must work without a client.
+	if diags := dataSourceCloudscaleCloudInitConfigRead(context.Background(), d, nil); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if got := d.Get("rendered").(string); got != testCloudConfig {
+		t.Errorf("rendered = %q, want %q", got, testCloudConfig)
+	}
+	if d.Id() == "" {
+		t.Error("expected the ID to be set")
+	}
+}
@@ -20,6 +20,6 @@ func dataSourceCloudscaleCustomImage() *schema.Resource {
 }
 
 func listCustomImages(ctx context.Context, d *schema.ResourceData, meta any) ([]cloudscale.CustomImage, error) {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.CustomImages.List(ctx)
 }
@@ -20,6 +20,6 @@ func dataSourceCloudscaleFloatingIP() *schema.Resource {
 }
 
 func listFloatingIPs(ctx context.Context, d *schema.ResourceData, meta any) ([]cloudscale.FloatingIP, error) {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.FloatingIPs.List(ctx)
 }
\ No newline at end of file
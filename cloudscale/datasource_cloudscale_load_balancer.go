@@ -19,6 +19,6 @@ func dataSourceCloudscaleLoadBalancer() *schema.Resource {
 }
 
 func listLoadBalancers(ctx context.Context, d *schema.ResourceData, meta any) ([]cloudscale.LoadBalancer, error) {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.LoadBalancers.List(ctx)
 }
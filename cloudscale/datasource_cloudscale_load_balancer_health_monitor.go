@@ -19,6 +19,6 @@ func dataSourceCloudscaleLoadBalancerHealthMonitor() *schema.Resource {
 }
 
 func listLoadBalancerHealthMonitors(ctx context.Context, d *schema.ResourceData, meta any) ([]cloudscale.LoadBalancerHealthMonitor, error) {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.LoadBalancerHealthMonitors.List(ctx)
 }
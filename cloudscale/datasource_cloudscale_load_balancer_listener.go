@@ -19,6 +19,6 @@ func dataSourceCloudscaleLoadBalancerListener() *schema.Resource {
 }
 
 func listLoadBalancerListeners(ctx context.Context, d *schema.ResourceData, meta any) ([]cloudscale.LoadBalancerListener, error) {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.LoadBalancerListeners.List(ctx)
 }
@@ -19,6 +19,6 @@ func dataSourceCloudscaleLoadBalancerPool() *schema.Resource {
 }
 
 func listLoadBalancerPools(ctx context.Context, d *schema.ResourceData, meta any) ([]cloudscale.LoadBalancerPool, error) {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.LoadBalancerPools.List(ctx)
 }
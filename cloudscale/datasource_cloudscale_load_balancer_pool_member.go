@@ -19,7 +19,7 @@ func dataSourceCloudscaleLoadBalancerPoolMember() *schema.Resource {
 }
 
 func listLoadBalancerPoolMembers(ctx context.Context, d *schema.ResourceData, meta any) ([]cloudscale.LoadBalancerPoolMember, error) {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	poolId := d.Get("pool_uuid").(string)
 	return client.LoadBalancerPoolMembers.List(ctx, poolId)
 }
@@ -20,6 +20,6 @@ func dataSourceCloudscaleNetwork() *schema.Resource {
 }
 
 func listNetworks(ctx context.Context, d *schema.ResourceData, meta any) ([]cloudscale.Network, error) {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.Networks.List(ctx)
 }
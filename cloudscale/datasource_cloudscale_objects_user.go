@@ -20,6 +20,6 @@ func dataSourceCloudscaleObjectsUser() *schema.Resource {
 }
 
 func listObjectsUsers(ctx context.Context, d *schema.ResourceData, meta any) ([]cloudscale.ObjectsUser, error) {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.ObjectsUsers.List(ctx)
 }
@@ -20,6 +20,6 @@ func dataSourceCloudscaleRouter() *schema.Resource {
 }
 
 func listRouters(ctx context.Context, d *schema.ResourceData, meta any) ([]cloudscale.Router, error) {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.Routers.List(ctx)
 }
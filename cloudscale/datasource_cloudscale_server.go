@@ -20,6 +20,6 @@ func dataSourceCloudscaleServer() *schema.Resource {
 }
 
 func listServers(ctx context.Context, d *schema.ResourceData, meta any) ([]cloudscale.Server, error) {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.Servers.List(ctx)
 }
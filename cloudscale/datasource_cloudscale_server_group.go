@@ -20,6 +20,6 @@ func dataSourceCloudscaleServerGroup() *schema.Resource {
 }
 
 func listServerGroups(ctx context.Context, d *schema.ResourceData, meta any) ([]cloudscale.ServerGroup, error) {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.ServerGroups.List(ctx)
 }
@@ -41,8 +41,8 @@ func TestAccCloudscaleServer_DS_Basic(t *testing.T) {
 						"data.cloudscale_server.foo", "flavor_slug", "flex-4-1"),
 					resource.TestCheckResourceAttr(
 						"data.cloudscale_server.foo", "image_slug", DefaultImageSlug),
-					resource.TestCheckResourceAttr(
-						"data.cloudscale_server.foo", "interfaces.0.type", "public"),
+					resource.TestCheckTypeSetElemNestedAttrs(
+						"data.cloudscale_server.foo", "interfaces.*", map[string]string{"type": "public"}),
 					resource.TestCheckResourceAttr(
 						"data.cloudscale_server.foo", "ssh_host_keys.#", "3"),
 					testAccCheckServerIp("data.cloudscale_server.foo"),
@@ -20,6 +20,6 @@ func dataSourceCloudscaleSubnet() *schema.Resource {
 }
 
 func listSubnets(ctx context.Context, d *schema.ResourceData, meta any) ([]cloudscale.Subnet, error) {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.Subnets.List(ctx)
 }
@@ -0,0 +1,306 @@
+package cloudscale
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/cloudscale-ch/cloudscale-go-sdk/v10"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+const (
+	subnetAddressTypeGateway            = "gateway"
+	subnetAddressTypeServer             = "server"
+	subnetAddressTypeRouter             = "router"
+	subnetAddressTypeLoadBalancer       = "load_balancer"
+	subnetAddressTypeLoadBalancerMember = "load_balancer_pool_member"
+)
+
+// dataSourceCloudscaleSubnetAddresses reports the addresses in use in a subnet
+// and the next free ones, so that static addresses can be allocated without
+// collisions. Unlike the other data sources it doesn't look up one object,
+// but aggregates the addresses of servers, routers and load balancers.
+func dataSourceCloudscaleSubnetAddresses() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceCloudscaleSubnetAddressesRead,
+		Schema:      getSubnetAddressesSchema(),
+	}
+}
+
+func getSubnetAddressesSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"subnet_uuid": {
+			Type:     schema.TypeString,
+			Required: true,
+		},
+		"free_address_count": {
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Default:      1,
+			ValidateFunc: validation.IntBetween(0, 1024),
+		},
+		"exclude": {
+			Type:     schema.TypeList,
+			Optional: true,
+			Elem: &schema.Schema{
+				Type:         schema.TypeString,
+				ValidateFunc: validateAddressRange,
+			},
+		},
+		"cidr": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"used_addresses": {
+			Type:     schema.TypeList,
+			Computed: true,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"address": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+					"type": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+					"uuid": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+				},
+			},
+		},
+		"free_addresses": {
+			Type:     schema.TypeList,
+			Elem:     &schema.Schema{Type: schema.TypeString},
+			Computed: true,
+		},
+	}
+}
+
+// subnetAddressUse is an address in a subnet and what uses it.
+type subnetAddressUse struct {
+	Address netip.Addr
+	Type    string
+	UUID    string
+}
+
+// addressRange is an inclusive range of addresses.
+type addressRange struct {
+	From netip.Addr
+	To   netip.Addr
+}
+
+func (r addressRange) contains(addr netip.Addr) bool {
+	return r.From.Compare(addr) <= 0 && addr.Compare(r.To) <= 0
+}
+
+// parseAddressRange accepts a CIDR, e.g. 10.11.12.0/28, a range, e.g.
+// 10.11.12.10-10.11.12.20, or a single address.
+func parseAddressRange(s string) (addressRange, error) {
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return addressRange{}, err
+		}
+		return addressRange{From: prefix.Masked().Addr(), To: lastPrefixAddr(prefix)}, nil
+	}
+	if from, to, ok := strings.Cut(s, "-"); ok {
+		fromAddr, err := netip.ParseAddr(strings.TrimSpace(from))
+		if err != nil {
+			return addressRange{}, err
+		}
+		toAddr, err := netip.ParseAddr(strings.TrimSpace(to))
+		if err != nil {
+			return addressRange{}, err
+		}
+		if fromAddr.BitLen() != toAddr.BitLen() || toAddr.Less(fromAddr) {
+			return addressRange{}, fmt.Errorf("%s is not a range from a lower to a higher address", s)
+		}
+		return addressRange{From: fromAddr, To: toAddr}, nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return addressRange{}, err
+	}
+	return addressRange{From: addr, To: addr}, nil
+}
+
+func validateAddressRange(v any, k string) ([]string, []error) {
+	if _, err := parseAddressRange(v.(string)); err != nil {
+		return nil, []error{fmt.Errorf("expected %s to be a CIDR, a range of addresses or an address, got %q: %s", k, v, err)}
+	}
+	return nil, nil
+}
+
+func lastPrefixAddr(prefix netip.Prefix) netip.Addr {
+	prefix = prefix.Masked()
+	b := prefix.Addr().AsSlice()
+	for i := prefix.Bits(); i < len(b)*8; i++ {
+		b[i/8] |= 1 << (7 - i%8)
+	}
+	addr, _ := netip.AddrFromSlice(b)
+	return addr
+}
+
+// freeSubnetAddresses returns up to count addresses of the subnet that are
+// neither used nor excluded, in ascending order. The network address and, for
+// IPv4, the broadcast address are never free.
+func freeSubnetAddresses(prefix netip.Prefix, used []subnetAddressUse, excluded []addressRange, count int) []string {
+	usedAddrs := make(map[netip.Addr]bool, len(used))
+	for _, use := range used {
+		usedAddrs[use.Address] = true
+	}
+
+	last := lastPrefixAddr(prefix)
+	if prefix.Addr().Is4() {
+		last = last.Prev()
+	}
+
+	free := make([]string, 0, count)
+	for addr := prefix.Masked().Addr().Next(); len(free) < count && addr.IsValid() && addr.Compare(last) <= 0; addr = addr.Next() {
+		if i := slices.IndexFunc(excluded, func(r addressRange) bool { return r.contains(addr) }); i >= 0 {
+			// Skip the whole range, which may be large.
+			addr = excluded[i].To
+			continue
+		}
+		if !usedAddrs[addr] {
+			free = append(free, addr.String())
+		}
+	}
+	return free
+}
+
+func dataSourceCloudscaleSubnetAddressesRead(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+	client := meta.(*providerMeta).Client
+	subnetUUID := d.Get("subnet_uuid").(string)
+
+	subnet, err := client.Subnets.Get(ctx, subnetUUID)
+	if err != nil {
+		return diag.Errorf("Error retrieving subnet (%s): %s", subnetUUID, err)
+	}
+	prefix, err := netip.ParsePrefix(subnet.CIDR)
+	if err != nil {
+		return diag.Errorf("Error parsing the CIDR of subnet (%s): %s", subnetUUID, err)
+	}
+
+	used, err := listSubnetAddressUses(ctx, client, subnet)
+	if err != nil {
+		return diag.Errorf("Error retrieving the addresses of subnet (%s): %s", subnetUUID, err)
+	}
+
+	var excluded []addressRange
+	for _, raw := range d.Get("exclude").([]any) {
+		r, err := parseAddressRange(raw.(string))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		excluded = append(excluded, r)
+	}
+
+	usedAddresses := make([]map[string]any, 0, len(used))
+	for _, use := range used {
+		usedAddresses = append(usedAddresses, map[string]any{
+			"address": use.Address.String(),
+			"type":    use.Type,
+			"uuid":    use.UUID,
+		})
+	}
+
+	d.SetId(subnet.UUID)
+	d.Set("cidr", subnet.CIDR)
+	d.Set("used_addresses", usedAddresses)
+	d.Set("free_addresses", freeSubnetAddresses(prefix, used, excluded, d.Get("free_address_count").(int)))
+	return nil
+}
+
+// listSubnetAddressUses aggregates the addresses in the subnet from its
+// gateway, server interfaces, router interfaces, load balancer VIPs and pool
+// members, sorted by address. Only the members of pools whose load balancer
+// has a VIP in the subnet are listed, which saves a request per other pool.
+func listSubnetAddressUses(ctx context.Context, client *cloudscale.Client, subnet *cloudscale.Subnet) ([]subnetAddressUse, error) {
+	var used []subnetAddressUse
+	add := func(address, addressType, uuid string) {
+		addr, err := netip.ParseAddr(address)
+		if err != nil {
+			return
+		}
+		used = append(used, subnetAddressUse{Address: addr, Type: addressType, UUID: uuid})
+	}
+
+	if subnet.GatewayAddress != "" {
+		add(subnet.GatewayAddress, subnetAddressTypeGateway, subnet.UUID)
+	}
+
+	servers, err := client.Servers.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, server := range servers {
+		for _, iface := range server.Interfaces {
+			for _, addr := range iface.Addresses {
+				if addr.Subnet.UUID == subnet.UUID {
+					add(addr.Address, subnetAddressTypeServer, server.UUID)
+				}
+			}
+		}
+	}
+
+	routers, err := client.Routers.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, router := range routers {
+		for _, iface := range router.Interfaces {
+			for _, addr := range iface.Addresses {
+				if addr.Subnet.UUID == subnet.UUID {
+					add(addr.Address, subnetAddressTypeRouter, router.UUID)
+				}
+			}
+		}
+	}
+
+	loadBalancers, err := client.LoadBalancers.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	inSubnet := make(map[string]bool)
+	for _, loadBalancer := range loadBalancers {
+		for _, vip := range loadBalancer.VIPAddresses {
+			if vip.Subnet.UUID == subnet.UUID {
+				add(vip.Address, subnetAddressTypeLoadBalancer, loadBalancer.UUID)
+				inSubnet[loadBalancer.UUID] = true
+			}
+		}
+	}
+
+	pools, err := client.LoadBalancerPools.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, pool := range pools {
+		if !inSubnet[pool.LoadBalancer.UUID] {
+			continue
+		}
+		members, err := client.LoadBalancerPoolMembers.List(ctx, pool.UUID)
+		if err != nil {
+			return nil, err
+		}
+		for _, member := range members {
+			if member.Subnet.UUID == subnet.UUID {
+				add(member.Address, subnetAddressTypeLoadBalancerMember, member.UUID)
+			}
+		}
+	}
+
+	slices.SortStableFunc(used, func(a, b subnetAddressUse) int {
+		return a.Address.Compare(b.Address)
+	})
+	return used, nil
+}
@@ -0,0 +1,172 @@
+package cloudscale
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"reflect"
+	"testing"
+
+	"github.com/cloudscale-ch/cloudscale-go-sdk/v10"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestParseAddressRange(t *testing.T) {
+	for s, expected := range map[string]addressRange{
+		"10.11.12.16/28":          {netip.MustParseAddr("10.11.12.16"), netip.MustParseAddr("10.11.12.31")},
+		"10.11.12.10-10.11.12.20": {netip.MustParseAddr("10.11.12.10"), netip.MustParseAddr("10.11.12.20")},
+		"10.11.12.10":             {netip.MustParseAddr("10.11.12.10"), netip.MustParseAddr("10.11.12.10")},
+		"2001:db8::/126":          {netip.MustParseAddr("2001:db8::"), netip.MustParseAddr("2001:db8::3")},
+	} {
+		actual, err := parseAddressRange(s)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", s, err)
+		} else if actual != expected {
+			t.Errorf("%s: got %v, want %v", s, actual, expected)
+		}
+	}
+	for _, s := range []string{"10.11.12.20-10.11.12.10", "10.11.12.1-2001:db8::1", "10.11.12.0/33", "not-an-address"} {
+		if _, err := parseAddressRange(s); err == nil {
+			t.Errorf("%s: expected an error", s)
+		}
+	}
+}
+
+func TestFreeSubnetAddresses(t *testing.T) {
+	prefix := netip.MustParsePrefix("10.11.12.0/29")
+	used := []subnetAddressUse{
+		{Address: netip.MustParseAddr("10.11.12.1"), Type: subnetAddressTypeGateway},
+		{Address: netip.MustParseAddr("10.11.12.3"), Type: subnetAddressTypeServer},
+	}
+	excluded := []addressRange{
+		{netip.MustParseAddr("10.11.12.4"), netip.MustParseAddr("10.11.12.5")},
+	}
+
+	for count, expected := range map[int][]string{
+		0:  {},
+		1:  {"10.11.12.2"},
+		2:  {"10.11.12.2", "10.11.12.6"},
+		10: {"10.11.12.2", "10.11.12.6"},
+	} {
+		actual := freeSubnetAddresses(prefix, used, excluded, count)
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("count %d: got %v, want %v", count, actual, expected)
+		}
+	}
+
+	// An exclusion covering the rest of the subnet ends the search.
+	excluded = []addressRange{
+		{netip.MustParseAddr("10.11.12.2"), netip.MustParseAddr("10.255.255.255")},
+	}
+	if actual := freeSubnetAddresses(prefix, used, excluded, 10); len(actual) != 0 {
+		t.Errorf("got %v, want no free addresses", actual)
+	}
+}
+
+func TestListSubnetAddressUses(t *testing.T) {
+	mux := http.NewServeMux()
+	serveJSON := func(path, body string) {
+		mux.HandleFunc("GET "+path, func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, body)
+		})
+	}
+	serveJSON("/v1/servers", `[]`)
+	serveJSON("/v1/routers", `[]`)
+	serveJSON("/v1/load-balancers", `[
+		{"uuid": "lb-1", "vip_addresses": [{"address": "10.11.12.10", "subnet": {"uuid": "subnet-1"}}]},
+		{"uuid": "lb-2", "vip_addresses": [{"address": "192.0.2.10", "subnet": {"uuid": "subnet-2"}}]}
+	]`)
+	serveJSON("/v1/load-balancers/pools", `[
+		{"uuid": "pool-1", "load_balancer": {"uuid": "lb-1"}},
+		{"uuid": "pool-2", "load_balancer": {"uuid": "lb-2"}}
+	]`)
+	serveJSON("/v1/load-balancers/pools/pool-1/members", `[
+		{"uuid": "member-1", "address": "10.11.12.20", "subnet": {"uuid": "subnet-1"}}
+	]`)
+	mux.HandleFunc("GET /v1/load-balancers/pools/pool-2/members", func(w http.ResponseWriter, _ *http.Request) {
+		t.Error("listed the members of a pool without a VIP in the subnet")
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	client := testClient(t, mux)
+
+	used, err := listSubnetAddressUses(context.Background(), client.Client, &cloudscale.Subnet{
+		UUID:           "subnet-1",
+		GatewayAddress: "10.11.12.1",
+	})
+	if err != nil {
+		t.Fatalf("listSubnetAddressUses: %s", err)
+	}
+
+	expected := []subnetAddressUse{
+		{Address: netip.MustParseAddr("10.11.12.1"), Type: subnetAddressTypeGateway, UUID: "subnet-1"},
+		{Address: netip.MustParseAddr("10.11.12.10"), Type: subnetAddressTypeLoadBalancer, UUID: "lb-1"},
+		{Address: netip.MustParseAddr("10.11.12.20"), Type: subnetAddressTypeLoadBalancerMember, UUID: "member-1"},
+	}
+	if !reflect.DeepEqual(used, expected) {
+		t.Errorf("got %v, want %v", used, expected)
+	}
+}
+
+func TestAccCloudscaleSubnetAddresses_Basic(t *testing.T) {
+	rInt := acctest.RandInt()
+	dataSourceName := "data.cloudscale_subnet_addresses.basic"
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudscaleSubnetDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: subnetAddressesConfig_basic(rInt),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair(
+						dataSourceName, "id", "cloudscale_subnet.basic", "id"),
+					resource.TestCheckResourceAttr(
+						dataSourceName, "cidr", "10.11.12.0/24"),
+					resource.TestCheckResourceAttr(
+						dataSourceName, "used_addresses.#", "2"),
+					resource.TestCheckResourceAttr(
+						dataSourceName, "used_addresses.0.address", "10.11.12.1"),
+					resource.TestCheckResourceAttr(
+						dataSourceName, "used_addresses.0.type", "gateway"),
+					resource.TestCheckResourceAttr(
+						dataSourceName, "used_addresses.1.address", "10.11.12.2"),
+					resource.TestCheckResourceAttr(
+						dataSourceName, "used_addresses.1.type", "server"),
+					resource.TestCheckResourceAttrPair(
+						dataSourceName, "used_addresses.1.uuid", "cloudscale_server.basic", "id"),
+					resource.TestCheckResourceAttr(
+						dataSourceName, "free_addresses.#", "3"),
+					resource.TestCheckResourceAttr(
+						dataSourceName, "free_addresses.0", "10.11.12.5"),
+					resource.TestCheckResourceAttr(
+						dataSourceName, "free_addresses.1", "10.11.12.6"),
+					resource.TestCheckResourceAttr(
+						dataSourceName, "free_addresses.2", "10.11.12.7"),
+				),
+			},
+		},
+	})
+}
+
+func subnetAddressesConfig_basic(rInt int) string {
+	return networkconfigMinimal(rInt, false) + fmt.Sprintf(`
+resource "cloudscale_subnet" "basic" {
+  cidr            = "10.11.12.0/24"
+  network_uuid    = cloudscale_network.basic.id
+  gateway_address = "10.11.12.1"
+}
+%s
+
+data "cloudscale_subnet_addresses" "basic" {
+  subnet_uuid        = cloudscale_subnet.basic.id
+  free_address_count = 3
+  exclude            = ["10.11.12.3-10.11.12.4"]
+
+  depends_on = [cloudscale_server.basic]
+}
+`, serverConfigWithPublicAndLayerThree(rInt, "10.11.12.2"))
+}
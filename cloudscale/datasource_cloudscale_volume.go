@@ -20,6 +20,6 @@ func dataSourceCloudscaleVolume() *schema.Resource {
 }
 
 func listVolumes(ctx context.Context, d *schema.ResourceData, meta any) ([]cloudscale.Volume, error) {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.Volumes.List(ctx)
 }
@@ -20,6 +20,6 @@ func dataSourceCloudscaleVolumeSnapshot() *schema.Resource {
 }
 
 func listVolumeSnapshots(ctx context.Context, d *schema.ResourceData, meta any) ([]cloudscale.VolumeSnapshot, error) {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.VolumeSnapshots.List(ctx)
 }
@@ -2,9 +2,14 @@ package cloudscale
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"net/http"
+	"time"
 
 	"github.com/cloudscale-ch/cloudscale-go-sdk/v10"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
@@ -12,7 +17,8 @@ import (
 // sub-resource operation (pools, pool members, listeners, health monitors)
 // mutates its load balancer. Concurrency buys nothing here: the API serializes
 // the requests anyway; so we serialize them ourselves. This file derives the
-// lock keys for that: one per load balancer.
+// lock keys for that (one per load balancer) and holds the lock until the load
+// balancer has applied the change.
 
 // lbLockKey returns the mutex key identifying a single load balancer. Every
 // operation that mutates the load balancer or any of its sub-resources locks on
@@ -21,27 +27,150 @@ func lbLockKey(lbUUID string) string {
 	return fmt.Sprintf("cloudscale/load-balancer/%s", lbUUID)
 }
 
-// lockKeyFromLoadBalancerUUID derives the lock key for the load balancer named by the
-// load_balancer_uuid attribute. It is for resources that carry that UUID in their own schema, so
-// the key comes straight from state without a lookup.
-var lockKeyFromLoadBalancerUUID = uuidLockKey("load_balancer_uuid", lbLockKey)
+// loadBalancerUUIDFunc resolves the load balancer that a sub-resource operation mutates. It
+// returns an error when the load balancer can't be determined, so the operation fails rather than
+// run unserialized.
+type loadBalancerUUIDFunc func(ctx context.Context, d *schema.ResourceData, meta any) (string, error)
 
-// lockKeyFromPoolUUID derives the lock key for the load balancer that owns the pool named by
-// pool_uuid. It is for resources that carry pool_uuid but not load_balancer_uuid, so the load
-// balancer is resolved via the API. It returns an error when the load balancer can't be determined,
-// e.g.: pool_uuid unset, the lookup fails, or the pool has no load balancer.
-func lockKeyFromPoolUUID(ctx context.Context, d *schema.ResourceData, meta any) (string, error) {
+// loadBalancerUUIDFromAttribute reads the load balancer from the load_balancer_uuid attribute. It
+// is for resources that carry that UUID in their own schema, so it comes straight from state
+// without a lookup.
+func loadBalancerUUIDFromAttribute(_ context.Context, d *schema.ResourceData, _ any) (string, error) {
+	lbUUID, ok := d.GetOk("load_balancer_uuid")
+	if !ok {
+		return "", fmt.Errorf("cannot determine the load balancer to lock: load_balancer_uuid is not set")
+	}
+	return lbUUID.(string), nil
+}
+
+// loadBalancerUUIDFromPoolUUID resolves the load balancer that owns the pool named by pool_uuid.
+// It is for resources that carry pool_uuid but not load_balancer_uuid, so the load balancer is
+// resolved via the API, once per pool and provider instance: a pool can't move to another load
+// balancer. It returns an error when the load balancer can't be determined, e.g.: pool_uuid
+// unset, the lookup fails, or the pool has no load balancer.
+func loadBalancerUUIDFromPoolUUID(ctx context.Context, d *schema.ResourceData, meta any) (string, error) {
 	poolUUID, ok := d.GetOk("pool_uuid")
 	if !ok {
 		return "", fmt.Errorf("cannot determine the load balancer to lock: pool_uuid is not set")
 	}
-	client := meta.(*cloudscale.Client)
-	pool, err := client.LoadBalancerPools.Get(ctx, poolUUID.(string))
+	m := meta.(*providerMeta)
+	return m.poolLoadBalancers.get(ctx, poolUUID.(string), func(ctx context.Context) (string, error) {
+		return lookupPoolLoadBalancer(ctx, m.Client, poolUUID.(string))
+	})
+}
+
+func lookupPoolLoadBalancer(ctx context.Context, client *cloudscale.Client, poolUUID string) (string, error) {
+	pool, err := client.LoadBalancerPools.Get(ctx, poolUUID)
 	if err != nil {
 		return "", fmt.Errorf("cannot determine the load balancer to lock for pool %s: %w", poolUUID, err)
 	}
 	if pool.LoadBalancer.UUID == "" {
 		return "", fmt.Errorf("cannot determine the load balancer to lock: pool %s has no load balancer", poolUUID)
 	}
-	return lbLockKey(pool.LoadBalancer.UUID), nil
+	return pool.LoadBalancer.UUID, nil
+}
+
+// loadBalancerUUIDFromStateOrPool is for resources that store the load balancer of their pool in
+// a computed load_balancer_uuid. Once it is in state, no lookup is needed, even when the pool was
+// deleted out of band. Otherwise, e.g. when creating or after an import, the load balancer is
+// resolved via the pool.
+func loadBalancerUUIDFromStateOrPool(ctx context.Context, d *schema.ResourceData, meta any) (string, error) {
+	if lbUUID, ok := d.GetOk("load_balancer_uuid"); ok {
+		return lbUUID.(string), nil
+	}
+	return loadBalancerUUIDFromPoolUUID(ctx, d, meta)
+}
+
+// readWithLoadBalancerUUID wraps the read of a resource that uses loadBalancerUUIDFromStateOrPool.
+// The API doesn't return the load balancer with the resource, so it is filled in from the pool
+// when it's missing: after creating, after an import, or in state written by an older version.
+func readWithLoadBalancerUUID(read schema.ReadContextFunc) schema.ReadContextFunc {
+	return func(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+		diags := read(ctx, d, meta)
+		if diags.HasError() || d.Id() == "" {
+			return diags
+		}
+		if _, ok := d.GetOk("load_balancer_uuid"); ok {
+			return diags
+		}
+		lbUUID, err := loadBalancerUUIDFromPoolUUID(ctx, d, meta)
+		if err != nil {
+			return append(diags, diag.FromErr(err)...)
+		}
+		d.Set("load_balancer_uuid", lbUUID)
+		return diags
+	}
+}
+
+// lockLoadBalancerUntilRunning wraps a sub-resource operation so that it holds the lock of the
+// load balancer resolved by lbUUIDFunc, and keeps holding it until the load balancer is running
+// again. The API accepts a change before the load balancer has applied it; releasing the lock
+// right away would let the next operation fail with "load balancer is busy". The wait counts
+// against the resource timeout selected by timeoutKey, e.g. schema.TimeoutCreate.
+//
+// Nothing is pending when the operation found the resource gone and cleared its ID, so the wait
+// is skipped. When deleting, a load balancer that is gone as well, e.g. because it was deleted
+// together with the resource, counts as running.
+func lockLoadBalancerUntilRunning(
+	lbUUIDFunc loadBalancerUUIDFunc,
+	timeoutKey string,
+	op func(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics,
+) func(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+	return func(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+		startTime := time.Now()
+
+		lbUUID, err := lbUUIDFunc(ctx, d, meta)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		key := lbLockKey(lbUUID)
+		if err := globalMu.LockContext(ctx, key); err != nil {
+			return diag.FromErr(err)
+		}
+		defer globalMu.Unlock(key)
+
+		diags := op(ctx, d, meta)
+		if diags.HasError() || d.Id() == "" {
+			return diags
+		}
+
+		remainingTime := d.Timeout(timeoutKey) - time.Since(startTime)
+		err = waitForLoadBalancerRunning(ctx, lbUUID, &remainingTime, meta)
+		if cerr, ok := errors.AsType[*cloudscale.ErrorResponse](err); ok && cerr.StatusCode == http.StatusNotFound && timeoutKey == schema.TimeoutDelete {
+			log.Printf("[INFO] Load balancer (%s) is gone, nothing to wait for", lbUUID)
+			return diags
+		}
+		if err != nil {
+			return append(diags, diag.FromErr(err)...)
+		}
+		return diags
+	}
+}
+
+// waitForLoadBalancerRunning waits for the load balancer to apply its pending changes. The API
+// reports a load balancer as changing as soon as it accepts a change, so a load balancer that is
+// running right away has nothing pending and the wait is skipped.
+func waitForLoadBalancerRunning(ctx context.Context, lbUUID string, timeout *time.Duration, meta any) error {
+	client := meta.(*providerMeta).Client
+	refreshFunc := func() (any, string, error) {
+		loadBalancer, err := client.LoadBalancers.Get(ctx, lbUUID)
+		if err != nil {
+			return nil, "", fmt.Errorf("error retrieving load balancer (%s) (refresh) %w", lbUUID, err)
+		}
+		return loadBalancer, loadBalancer.Status, nil
+	}
+
+	_, status, err := refreshFunc()
+	if err != nil {
+		return err
+	}
+	if status == "running" {
+		return nil
+	}
+	log.Printf("[INFO] Waiting for load balancer (%s) to be running, status is %s", lbUUID, status)
+	_, err = waitForStatus(ctx, []string{"changing"}, "running", timeout, refreshFunc)
+	if err != nil {
+		return fmt.Errorf("error waiting for load balancer (%s) to be running: %w", lbUUID, err)
+	}
+	return nil
 }
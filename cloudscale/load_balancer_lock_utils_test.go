@@ -6,15 +6,18 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/cloudscale-ch/cloudscale-go-sdk/v10"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
-// testClient returns a cloudscale client whose requests are served by handler
-// instead of the real API.
-func testClient(t *testing.T, handler http.Handler) *cloudscale.Client {
+// testClient returns the meta of a provider instance whose requests are
+// served by handler instead of the real API.
+func testClient(t *testing.T, handler http.Handler) *providerMeta {
 	t.Helper()
 
 	server := httptest.NewServer(handler)
@@ -29,7 +32,7 @@ func testClient(t *testing.T, handler http.Handler) *cloudscale.Client {
 
 	client := cloudscale.NewClient(nil)
 	client.BaseURL = baseURL
-	return client
+	return newProviderMeta(client)
 }
 
 // poolHandler serves one pool at the path the SDK uses for pool reads.
@@ -82,7 +85,7 @@ func TestUuidLockKey(t *testing.T) {
 	})
 }
 
-func TestLockKeyFromPoolUUID(t *testing.T) {
+func TestLoadBalancerUUIDFromPoolUUID(t *testing.T) {
 	const (
 		poolUUID = "pool-1"
 		lbUUID   = "lb-1"
@@ -96,12 +99,110 @@ func TestLockKeyFromPoolUUID(t *testing.T) {
 		}))
 		d := schema.TestResourceDataRaw(t, memberSchema, map[string]any{"pool_uuid": poolUUID})
 
-		key, err := lockKeyFromPoolUUID(context.Background(), d, client)
+		got, err := loadBalancerUUIDFromPoolUUID(context.Background(), d, client)
 		if err != nil {
 			t.Fatalf("unexpected error: %s", err)
 		}
-		if want := "cloudscale/load-balancer/lb-1"; key != want {
-			t.Errorf("key = %q, want %q", key, want)
+		if got != lbUUID {
+			t.Errorf("load balancer = %q, want %q", got, lbUUID)
+		}
+	})
+
+	t.Run("looks up each pool once per provider instance", func(t *testing.T) {
+		requests := 0
+		handler := poolHandler(t, poolUUID, cloudscale.LoadBalancerPool{
+			UUID:         poolUUID,
+			LoadBalancer: cloudscale.LoadBalancerStub{UUID: lbUUID},
+		})
+		client := testClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			handler.ServeHTTP(w, r)
+		}))
+		d := schema.TestResourceDataRaw(t, memberSchema, map[string]any{"pool_uuid": poolUUID})
+
+		for range 3 {
+			got, err := loadBalancerUUIDFromPoolUUID(context.Background(), d, client)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got != lbUUID {
+				t.Errorf("load balancer = %q, want %q", got, lbUUID)
+			}
+		}
+		if requests != 1 {
+			t.Errorf("got %d requests, want 1", requests)
+		}
+	})
+
+	// A slow lookup must not hold up the members of other pools.
+	t.Run("looks up different pools concurrently", func(t *testing.T) {
+		otherPoolRequested := make(chan struct{})
+		mux := http.NewServeMux()
+		mux.HandleFunc("/v1/load-balancers/pools/{uuid}", func(w http.ResponseWriter, r *http.Request) {
+			uuid := r.PathValue("uuid")
+			if uuid == poolUUID {
+				select {
+				case <-otherPoolRequested:
+				case <-time.After(5 * time.Second):
+					http.Error(w, `{"detail": "the other pool was never looked up"}`, http.StatusInternalServerError)
+					return
+				}
+			} else {
+				close(otherPoolRequested)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(cloudscale.LoadBalancerPool{
+				UUID:         uuid,
+				LoadBalancer: cloudscale.LoadBalancerStub{UUID: lbUUID},
+			}); err != nil {
+				t.Errorf("encoding pool response: %s", err)
+			}
+		})
+		client := testClient(t, mux)
+
+		errs := make(chan error, 1)
+		go func() {
+			d := schema.TestResourceDataRaw(t, memberSchema, map[string]any{"pool_uuid": poolUUID})
+			_, err := loadBalancerUUIDFromPoolUUID(context.Background(), d, client)
+			errs <- err
+		}()
+		// Make sure the slow lookup is underway before looking up the other pool.
+		time.Sleep(100 * time.Millisecond)
+
+		d := schema.TestResourceDataRaw(t, memberSchema, map[string]any{"pool_uuid": "pool-2"})
+		if _, err := loadBalancerUUIDFromPoolUUID(context.Background(), d, client); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if err := <-errs; err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+	})
+
+	t.Run("looks up a pool again after a failed lookup", func(t *testing.T) {
+		requests := 0
+		handler := poolHandler(t, poolUUID, cloudscale.LoadBalancerPool{
+			UUID:         poolUUID,
+			LoadBalancer: cloudscale.LoadBalancerStub{UUID: lbUUID},
+		})
+		client := testClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			if requests == 1 {
+				http.Error(w, `{"detail": "boom"}`, http.StatusInternalServerError)
+				return
+			}
+			handler.ServeHTTP(w, r)
+		}))
+		d := schema.TestResourceDataRaw(t, memberSchema, map[string]any{"pool_uuid": poolUUID})
+
+		if _, err := loadBalancerUUIDFromPoolUUID(context.Background(), d, client); err == nil {
+			t.Fatal("expected the error of the first lookup")
+		}
+		got, err := loadBalancerUUIDFromPoolUUID(context.Background(), d, client)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if got != lbUUID {
+			t.Errorf("load balancer = %q, want %q", got, lbUUID)
 		}
 	})
 
@@ -109,7 +210,7 @@ func TestLockKeyFromPoolUUID(t *testing.T) {
 		client := testClient(t, http.NewServeMux())
 		d := schema.TestResourceDataRaw(t, memberSchema, map[string]any{})
 
-		if _, err := lockKeyFromPoolUUID(context.Background(), d, client); err == nil {
+		if _, err := loadBalancerUUIDFromPoolUUID(context.Background(), d, client); err == nil {
 			t.Error("expected an error when pool_uuid is unset")
 		}
 	})
@@ -124,7 +225,7 @@ func TestLockKeyFromPoolUUID(t *testing.T) {
 		client := testClient(t, mux)
 		d := schema.TestResourceDataRaw(t, memberSchema, map[string]any{"pool_uuid": poolUUID})
 
-		if _, err := lockKeyFromPoolUUID(context.Background(), d, client); err == nil {
+		if _, err := loadBalancerUUIDFromPoolUUID(context.Background(), d, client); err == nil {
 			t.Error("expected an error when the pool cannot be read")
 		}
 	})
@@ -138,12 +239,36 @@ func TestLockKeyFromPoolUUID(t *testing.T) {
 		}))
 		d := schema.TestResourceDataRaw(t, memberSchema, map[string]any{"pool_uuid": poolUUID})
 
-		if _, err := lockKeyFromPoolUUID(context.Background(), d, client); err == nil {
+		if _, err := loadBalancerUUIDFromPoolUUID(context.Background(), d, client); err == nil {
 			t.Error("expected an error when the pool has no load balancer")
 		}
 	})
 }
 
+func TestLoadBalancerUUIDFromStateOrPool(t *testing.T) {
+	memberSchema := getLoadBalancerPoolMemberSchema(RESOURCE)
+
+	// The pool was deleted out of band: the load balancer in state still
+	// allows locking, e.g. to delete the member.
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, `{"detail": "Not found."}`, http.StatusNotFound)
+	})
+	client := testClient(t, mux)
+	d := schema.TestResourceDataRaw(t, memberSchema, map[string]any{"pool_uuid": "pool-1"})
+	if err := d.Set("load_balancer_uuid", "lb-1"); err != nil {
+		t.Fatalf("setting load_balancer_uuid: %s", err)
+	}
+
+	got, err := loadBalancerUUIDFromStateOrPool(context.Background(), d, client)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got != "lb-1" {
+		t.Errorf("load balancer = %q, want %q", got, "lb-1")
+	}
+}
+
 // TestLoadBalancerSubResourcesShareLockKey is the regression test for the bug
 // where pool members and listeners serialized on their pool while pools
 // serialized on the load balancer, so operations on one load balancer never
@@ -160,34 +285,34 @@ func TestLoadBalancerSubResourcesShareLockKey(t *testing.T) {
 	}))
 
 	tests := []struct {
-		name        string
-		resSchema   map[string]*schema.Schema
-		raw         map[string]any
-		lockKeyFunc mutexKeyFunc
+		name       string
+		resSchema  map[string]*schema.Schema
+		raw        map[string]any
+		lbUUIDFunc loadBalancerUUIDFunc
 	}{
 		{
-			name:        "pool",
-			resSchema:   getLoadBalancerPoolSchema(RESOURCE),
-			raw:         map[string]any{"load_balancer_uuid": lbUUID},
-			lockKeyFunc: lockKeyFromLoadBalancerUUID,
+			name:       "pool",
+			resSchema:  getLoadBalancerPoolSchema(RESOURCE),
+			raw:        map[string]any{"load_balancer_uuid": lbUUID},
+			lbUUIDFunc: loadBalancerUUIDFromAttribute,
 		},
 		{
-			name:        "pool member",
-			resSchema:   getLoadBalancerPoolMemberSchema(RESOURCE),
-			raw:         map[string]any{"pool_uuid": poolUUID},
-			lockKeyFunc: lockKeyFromPoolUUID,
+			name:       "pool member",
+			resSchema:  getLoadBalancerPoolMemberSchema(RESOURCE),
+			raw:        map[string]any{"pool_uuid": poolUUID},
+			lbUUIDFunc: loadBalancerUUIDFromStateOrPool,
 		},
 		{
-			name:        "listener",
-			resSchema:   getLoadBalancerListenerSchema(RESOURCE),
-			raw:         map[string]any{"pool_uuid": poolUUID},
-			lockKeyFunc: lockKeyFromPoolUUID,
+			name:       "listener",
+			resSchema:  getLoadBalancerListenerSchema(RESOURCE),
+			raw:        map[string]any{"pool_uuid": poolUUID},
+			lbUUIDFunc: loadBalancerUUIDFromPoolUUID,
 		},
 		{
-			name:        "health monitor",
-			resSchema:   getLoadBalancerHealthMonitorSchema(RESOURCE),
-			raw:         map[string]any{"pool_uuid": poolUUID},
-			lockKeyFunc: lockKeyFromPoolUUID,
+			name:       "health monitor",
+			resSchema:  getLoadBalancerHealthMonitorSchema(RESOURCE),
+			raw:        map[string]any{"pool_uuid": poolUUID},
+			lbUUIDFunc: loadBalancerUUIDFromStateOrPool,
 		},
 	}
 
@@ -196,10 +321,11 @@ func TestLoadBalancerSubResourcesShareLockKey(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			d := schema.TestResourceDataRaw(t, tt.resSchema, tt.raw)
 
-			key, err := tt.lockKeyFunc(context.Background(), d, client)
+			uuid, err := tt.lbUUIDFunc(context.Background(), d, client)
 			if err != nil {
 				t.Fatalf("%s: %s", tt.name, err)
 			}
+			key := lbLockKey(uuid)
 			if key != want {
 				t.Errorf("%s locks on %q, want %q: every load balancer sub-resource must serialize on the same key",
 					tt.name, key, want)
@@ -207,3 +333,115 @@ func TestLoadBalancerSubResourcesShareLockKey(t *testing.T) {
 		})
 	}
 }
+
+func TestLockLoadBalancerUntilRunning(t *testing.T) {
+	const lbUUID = "lb-1"
+	lbUUIDFunc := func(context.Context, *schema.ResourceData, any) (string, error) {
+		return lbUUID, nil
+	}
+
+	var calls []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/load-balancers/"+lbUUID, func(w http.ResponseWriter, _ *http.Request) {
+		calls = append(calls, "get load balancer")
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(cloudscale.LoadBalancer{UUID: lbUUID, Status: "running"}); err != nil {
+			t.Errorf("encoding load balancer response: %s", err)
+		}
+	})
+	client := testClient(t, mux)
+
+	t.Run("checks the load balancer after the operation and unlocks", func(t *testing.T) {
+		calls = nil
+		op := lockLoadBalancerUntilRunning(lbUUIDFunc, schema.TimeoutCreate, func(context.Context, *schema.ResourceData, any) diag.Diagnostics {
+			calls = append(calls, "operation")
+			return nil
+		})
+		d := schema.TestResourceDataRaw(t, getLoadBalancerPoolSchema(RESOURCE), map[string]any{})
+		d.SetId("pool-1")
+
+		if diags := op(context.Background(), d, client); diags.HasError() {
+			t.Fatalf("unexpected error: %v", diags)
+		}
+		if want := []string{"operation", "get load balancer"}; !slices.Equal(calls, want) {
+			t.Errorf("calls = %v, want %v", calls, want)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		if err := globalMu.LockContext(ctx, lbLockKey(lbUUID)); err != nil {
+			t.Fatalf("the load balancer is still locked: %s", err)
+		}
+		globalMu.Unlock(lbLockKey(lbUUID))
+	})
+
+	t.Run("doesn't wait when the operation fails", func(t *testing.T) {
+		calls = nil
+		op := lockLoadBalancerUntilRunning(lbUUIDFunc, schema.TimeoutCreate, func(context.Context, *schema.ResourceData, any) diag.Diagnostics {
+			calls = append(calls, "operation")
+			return diag.Errorf("boom")
+		})
+		d := schema.TestResourceDataRaw(t, getLoadBalancerPoolSchema(RESOURCE), map[string]any{})
+		d.SetId("pool-1")
+
+		if diags := op(context.Background(), d, client); !diags.HasError() {
+			t.Fatal("expected the error of the operation")
+		}
+		if want := []string{"operation"}; !slices.Equal(calls, want) {
+			t.Errorf("calls = %v, want %v", calls, want)
+		}
+	})
+
+	// The operation got a 404 and cleared the ID: the resource was already
+	// gone, so the load balancer has nothing to apply.
+	t.Run("doesn't wait when the resource is gone", func(t *testing.T) {
+		calls = nil
+		op := lockLoadBalancerUntilRunning(lbUUIDFunc, schema.TimeoutUpdate, func(_ context.Context, d *schema.ResourceData, _ any) diag.Diagnostics {
+			calls = append(calls, "operation")
+			d.SetId("")
+			return nil
+		})
+		d := schema.TestResourceDataRaw(t, getLoadBalancerPoolSchema(RESOURCE), map[string]any{})
+		d.SetId("pool-1")
+
+		if diags := op(context.Background(), d, client); diags.HasError() {
+			t.Fatalf("unexpected error: %v", diags)
+		}
+		if want := []string{"operation"}; !slices.Equal(calls, want) {
+			t.Errorf("calls = %v, want %v", calls, want)
+		}
+	})
+}
+
+func TestLockLoadBalancerUntilRunningLoadBalancerGone(t *testing.T) {
+	const lbUUID = "lb-1"
+	lbUUIDFunc := func(context.Context, *schema.ResourceData, any) (string, error) {
+		return lbUUID, nil
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/load-balancers/"+lbUUID, func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, `{"detail": "Not found."}`, http.StatusNotFound)
+	})
+	client := testClient(t, mux)
+
+	for _, tc := range []struct {
+		timeoutKey string
+		expectErr  bool
+	}{
+		// The load balancer was deleted together with the resource.
+		{schema.TimeoutDelete, false},
+		{schema.TimeoutUpdate, true},
+	} {
+		t.Run(tc.timeoutKey, func(t *testing.T) {
+			op := lockLoadBalancerUntilRunning(lbUUIDFunc, tc.timeoutKey, func(context.Context, *schema.ResourceData, any) diag.Diagnostics {
+				return nil
+			})
+			d := schema.TestResourceDataRaw(t, getLoadBalancerPoolSchema(RESOURCE), map[string]any{})
+			d.SetId("pool-1")
+
+			if diags := op(context.Background(), d, client); diags.HasError() != tc.expectErr {
+				t.Errorf("got %v, want an error: %t", diags, tc.expectErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package cloudscale
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// memo remembers the result of a lookup per key, e.g. the slugs listed by an
+// endpoint. Concurrent lookups of a key wait for the first one instead of all
+// sending their own requests, while lookups of other keys proceed. Failures
+// are not remembered, so the next lookup of the key tries again.
+type memo[V any] struct {
+	mu      sync.Mutex
+	entries map[string]*memoEntry[V]
+}
+
+// memoEntry is the lookup of one key. Its result is set before done is closed.
+type memoEntry[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+func (m *memo[V]) get(ctx context.Context, key string, lookup func(ctx context.Context) (V, error)) (V, error) {
+	for {
+		m.mu.Lock()
+		if m.entries == nil {
+			m.entries = make(map[string]*memoEntry[V])
+		}
+		entry, found := m.entries[key]
+		if !found {
+			entry = &memoEntry[V]{done: make(chan struct{})}
+			m.entries[key] = entry
+		}
+		m.mu.Unlock()
+
+		if !found {
+			entry.value, entry.err = lookup(ctx)
+			if entry.err != nil {
+				m.mu.Lock()
+				delete(m.entries, key)
+				m.mu.Unlock()
+			}
+			close(entry.done)
+			return entry.value, entry.err
+		}
+
+		select {
+		case <-entry.done:
+		case <-ctx.Done():
+			var zero V
+			return zero, ctx.Err()
+		}
+		// A lookup that ended with the operation which started it says
+		// nothing about this operation, so it looks up again.
+		if (errors.Is(entry.err, context.Canceled) || errors.Is(entry.err, context.DeadlineExceeded)) && ctx.Err() == nil {
+			continue
+		}
+		return entry.value, entry.err
+	}
+}
@@ -0,0 +1,104 @@
+package cloudscale
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMemo(t *testing.T) {
+	t.Run("looks up each key once", func(t *testing.T) {
+		var m memo[string]
+		var lookups atomic.Int32
+		lookup := func(context.Context) (string, error) {
+			lookups.Add(1)
+			time.Sleep(50 * time.Millisecond)
+			return "value", nil
+		}
+
+		var wg sync.WaitGroup
+		for range 5 {
+			wg.Go(func() {
+				if value, err := m.get(context.Background(), "key", lookup); err != nil || value != "value" {
+					t.Errorf("got %q, %v", value, err)
+				}
+			})
+		}
+		wg.Wait()
+		if got := lookups.Load(); got != 1 {
+			t.Errorf("got %d lookups, want 1", got)
+		}
+	})
+
+	// A slow lookup must not hold up the lookups of other keys.
+	t.Run("looks up different keys concurrently", func(t *testing.T) {
+		var m memo[string]
+		otherLookedUp := make(chan struct{})
+		errs := make(chan error, 1)
+		go func() {
+			_, err := m.get(context.Background(), "slow", func(context.Context) (string, error) {
+				select {
+				case <-otherLookedUp:
+					return "slow", nil
+				case <-time.After(5 * time.Second):
+					return "", errors.New("the other key was never looked up")
+				}
+			})
+			errs <- err
+		}()
+		// Make sure the slow lookup is underway before looking up the other key.
+		time.Sleep(50 * time.Millisecond)
+
+		if _, err := m.get(context.Background(), "other", func(context.Context) (string, error) {
+			close(otherLookedUp)
+			return "other", nil
+		}); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("looks up again after a failure", func(t *testing.T) {
+		var m memo[string]
+		if _, err := m.get(context.Background(), "key", func(context.Context) (string, error) {
+			return "", errors.New("boom")
+		}); err == nil {
+			t.Fatal("expected the error of the lookup")
+		}
+		value, err := m.get(context.Background(), "key", func(context.Context) (string, error) {
+			return "value", nil
+		})
+		if err != nil || value != "value" {
+			t.Errorf("got %q, %v", value, err)
+		}
+	})
+
+	// The operation that started the lookup was cancelled, the one waiting
+	// for it wasn't: it must not fail with the other operation's error.
+	t.Run("looks up again when the first lookup was cancelled", func(t *testing.T) {
+		var m memo[string]
+		ctx, cancel := context.WithCancel(context.Background())
+		started := make(chan struct{})
+		go func() {
+			_, _ = m.get(ctx, "key", func(ctx context.Context) (string, error) {
+				close(started)
+				<-ctx.Done()
+				return "", ctx.Err()
+			})
+		}()
+		<-started
+
+		time.AfterFunc(50*time.Millisecond, cancel)
+		value, err := m.get(context.Background(), "key", func(context.Context) (string, error) {
+			return "value", nil
+		})
+		if err != nil || value != "value" {
+			t.Errorf("got %q, %v", value, err)
+		}
+	})
+}
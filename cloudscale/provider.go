@@ -21,6 +21,7 @@ func Provider(version string) *schema.Provider {
 			"cloudscale_volume":                       dataSourceCloudscaleVolume(),
 			"cloudscale_network":                      dataSourceCloudscaleNetwork(),
 			"cloudscale_subnet":                       dataSourceCloudscaleSubnet(),
+			"cloudscale_subnet_addresses":             dataSourceCloudscaleSubnetAddresses(),
 			"cloudscale_router":                       dataSourceCloudscaleRouter(),
 			"cloudscale_floating_ip":                  dataSourceCloudscaleFloatingIP(),
 			"cloudscale_objects_user":                 dataSourceCloudscaleObjectsUser(),
@@ -31,6 +32,7 @@ func Provider(version string) *schema.Provider {
 			"cloudscale_load_balancer_listener":       dataSourceCloudscaleLoadBalancerListener(),
 			"cloudscale_load_balancer_health_monitor": dataSourceCloudscaleLoadBalancerHealthMonitor(),
 			"cloudscale_volume_snapshot":              dataSourceCloudscaleVolumeSnapshot(),
+			"cloudscale_cloudinit_config":             dataSourceCloudscaleCloudInitConfig(),
 		},
 
 		ResourcesMap: map[string]*schema.Resource{
@@ -41,7 +43,9 @@ func Provider(version string) *schema.Provider {
 			"cloudscale_subnet":                       resourceCloudscaleSubnet(),
 			"cloudscale_router":                       resourceCloudscaleRouter(),
 			"cloudscale_interface":                    resourceCloudscaleInterface(),
+			"cloudscale_router_route":                 resourceCloudscaleRouterRoute(),
 			"cloudscale_floating_ip":                  resourceCloudscaleFloatingIP(),
+			"cloudscale_floating_ip_association":      resourceCloudscaleFloatingIPAssociation(),
 			"cloudscale_objects_user":                 resourceCloudscaleObjectsUser(),
 			"cloudscale_custom_image":                 resourceCloudscaleCustomImage(),
 			"cloudscale_load_balancer":                resourceCloudscaleLoadBalancer(),
@@ -61,6 +65,10 @@ func providerConfigureClient(version string) schema.ConfigureFunc {
 			Token:   d.Get("token").(string),
 			Version: version,
 		}
-		return config.Client()
+		client, err := config.Client()
+		if err != nil {
+			return nil, err
+		}
+		return newProviderMeta(client), nil
 	}
 }
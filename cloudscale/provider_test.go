@@ -68,7 +68,7 @@ func testTagsMatch(resource string) resource.TestCheckFunc {
 			return fmt.Errorf("No HREF found")
 		}
 
-		client := testAccProvider.Meta().(*cloudscale.Client)
+		client := testAccProvider.Meta().(*providerMeta).Client
 		ctx := context.Background()
 		req, err := client.NewRequest(ctx, http.MethodGet, href, nil)
 		if err != nil {
@@ -2,16 +2,24 @@ package cloudscale
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"maps"
 	"math"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 
 	"github.com/cloudscale-ch/cloudscale-go-sdk/v10"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 const customImageHumanName = "custom image"
@@ -30,7 +38,14 @@ func resourceCloudscaleCustomImage() *schema.Resource {
 		UpdateContext: resourceCustomImageUpdate,
 		DeleteContext: resourceCustomImageDelete,
 
+		Importer: &schema.ResourceImporter{
+			StateContext: importCustomImage,
+		},
 		Schema: getCustomImageSchema(RESOURCE),
+		CustomizeDiff: customdiff.Sequence(
+			verifyChangedCustomImageChecksums,
+			planCustomImageZones,
+		),
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(20 * time.Minute),
 		},
@@ -60,7 +75,6 @@ func getCustomImageSchema(t SchemaType) map[string]*schema.Schema {
 			Elem:     &schema.Schema{Type: schema.TypeString},
 			Required: t.isResource(),
 			Computed: t.isDataSource(),
-			ForceNew: true,
 		},
 		"slug": {
 			Type:     schema.TypeString,
@@ -91,9 +105,10 @@ func getCustomImageSchema(t SchemaType) map[string]*schema.Schema {
 		}
 	} else {
 		m["import_url"] = &schema.Schema{
-			Type:     schema.TypeString,
-			Required: true,
-			ForceNew: true,
+			Type:             schema.TypeString,
+			Required:         true,
+			ForceNew:         true,
+			DiffSuppressFunc: suppressUnknownImportURL,
 		}
 		m["import_source_format"] = &schema.Schema{
 			Type:     schema.TypeString,
@@ -104,6 +119,16 @@ func getCustomImageSchema(t SchemaType) map[string]*schema.Schema {
 			Type:     schema.TypeString,
 			Computed: true,
 		}
+		m["expected_checksums"] = &schema.Schema{
+			Type:     schema.TypeMap,
+			Elem:     &schema.Schema{Type: schema.TypeString},
+			Optional: true,
+		}
+		m["import_retries"] = &schema.Schema{
+			Type:         schema.TypeInt,
+			Optional:     true,
+			ValidateFunc: validation.IntAtLeast(0),
+		}
 		m["import_status"] = &schema.Schema{
 			Type:     schema.TypeString,
 			Computed: true,
@@ -116,11 +141,60 @@ func getCustomImageSchema(t SchemaType) map[string]*schema.Schema {
 	return m
 }
 
+// suppressUnknownImportURL keeps an imported image from being replaced when
+// its import is no longer listed, so the import URL could not be read back.
+// The import URL is required, so it is only empty after such an import.
+func suppressUnknownImportURL(_, old, _ string, d *schema.ResourceData) bool {
+	return old == "" && d.Id() != ""
+}
+
+// importCustomImage adopts an existing custom image. The import attributes
+// are taken from the custom image import that created the image, so that the
+// next plan doesn't replace the image.
+func importCustomImage(ctx context.Context, d *schema.ResourceData, meta any) ([]*schema.ResourceData, error) {
+	client := meta.(*providerMeta).Client
+
+	customImageImport, err := findCustomImageImport(ctx, client, d.Id())
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving the import of custom image (%s): %s", d.Id(), err)
+	}
+	if customImageImport == nil {
+		log.Printf("[WARN] No import found for custom image %s", d.Id())
+		return []*schema.ResourceData{d}, nil
+	}
+
+	err = d.Set("import_url", customImageImport.URL)
+	if err != nil {
+		return nil, err
+	}
+	d.Set("import_href", customImageImport.HREF)
+	d.Set("import_uuid", customImageImport.UUID)
+	d.Set("import_status", customImageImport.Status)
+	return []*schema.ResourceData{d}, nil
+}
+
+// findCustomImageImport returns the most recent import of the custom image
+// or nil if there is none.
+func findCustomImageImport(ctx context.Context, client *cloudscale.Client, customImageUUID string) (*cloudscale.CustomImageImport, error) {
+	imports, err := client.CustomImageImports.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var found *cloudscale.CustomImageImport
+	for i := range imports {
+		if imports[i].CustomImage.UUID == customImageUUID {
+			found = &imports[i]
+		}
+	}
+	return found, nil
+}
+
 func createCustomImage(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
 	timeout := d.Timeout(schema.TimeoutCreate)
 	startTime := time.Now()
 
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 
 	opts := &cloudscale.CustomImageImportRequest{
 		URL:              d.Get("import_url").(string),
@@ -146,21 +220,47 @@ func createCustomImage(ctx context.Context, d *schema.ResourceData, meta any) di
 
 	log.Printf("[DEBUG] CustomImage create configuration: %#v", opts)
 
-	customImageImport, err := client.CustomImageImports.Create(ctx, opts)
-	if err != nil {
-		return diag.FromErr(fmt.Errorf("Error creating customImageImport: %z", err))
-	}
+	attempts := d.Get("import_retries").(int) + 1
+	var customImageImport *cloudscale.CustomImageImport
+	for attempt := 1; ; attempt++ {
+		var err error
+		customImageImport, err = client.CustomImageImports.Create(ctx, opts)
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("Error creating customImageImport: %z", err))
+		}
 
-	d.SetId(customImageImport.CustomImage.UUID)
+		d.SetId(customImageImport.CustomImage.UUID)
 
-	log.Printf("[INFO] CustomImage ID %s", d.Id())
+		log.Printf("[INFO] CustomImage ID %s", d.Id())
 
-	remainingTime := timeout - time.Since(startTime)
-	_, err = waitForCustomImageImportStatus(ctx, customImageImport.UUID, d, meta, []string{"in_progress"}, "import_status", "success", remainingTime)
-	if err != nil {
-		return diag.FromErr(fmt.Errorf("Error waiting for custom image import status (%s) (%s) ", customImageImport.UUID, err))
+		remainingTime := timeout - time.Since(startTime)
+		_, err = waitForCustomImageImportStatus(ctx, customImageImport.UUID, d, meta, []string{"in_progress"}, "import_status", "success", remainingTime)
+		if err == nil {
+			break
+		}
+		importErr, ok := errors.AsType[*customImageImportFailedError](err)
+		if !ok {
+			// The image is kept in state, Terraform taints it.
+			return diag.FromErr(fmt.Errorf("Error waiting for custom image import status (%s) (%s) ", customImageImport.UUID, err))
+		}
+
+		// A failed import leaves an unusable image behind, which is removed
+		// before importing again or giving up.
+		if err := deleteFailedCustomImage(ctx, client, d.Id()); err != nil {
+			return diag.FromErr(fmt.Errorf("%s, and deleting the custom image (%s) failed: %s", importErr, d.Id(), err))
+		}
+		d.SetId("")
+
+		if !importErr.Retryable() {
+			return diag.FromErr(fmt.Errorf("%s (attempt %d of %d, not retried)", importErr, attempt, attempts))
+		}
+		if attempt >= attempts {
+			return diag.FromErr(fmt.Errorf("%s (attempt %d of %d)", importErr, attempt, attempts))
+		}
+		log.Printf("[WARN] %s (attempt %d of %d), retrying", importErr, attempt, attempts)
 	}
-	customImageImport, err = client.CustomImageImports.Get(ctx, customImageImport.UUID)
+
+	customImageImport, err := client.CustomImageImports.Get(ctx, customImageImport.UUID)
 	if err != nil {
 		return diag.FromErr(fmt.Errorf("Error getting customImage: %z", err))
 	}
@@ -169,10 +269,54 @@ func createCustomImage(ctx context.Context, d *schema.ResourceData, meta any) di
 		return diag.FromErr(fmt.Errorf("Error getting customImage: %z", err))
 	}
 
+	expectedChecksums := d.Get("expected_checksums").(map[string]any)
+	if err := verifyCustomImageChecksums(expectedChecksums, customImage.Checksums); err != nil {
+		// Don't keep an image that is not what we expected to upload.
+		log.Printf("[WARN] Deleting custom image %s: %s", customImage.UUID, err)
+		if deleteErr := client.CustomImages.Delete(ctx, customImage.UUID); deleteErr != nil {
+			return diag.FromErr(fmt.Errorf("%s, and deleting the custom image (%s) failed: %s", err, customImage.UUID, deleteErr))
+		}
+		d.SetId("")
+		return diag.FromErr(fmt.Errorf("%s, the custom image was deleted", err))
+	}
+
 	fillCustomImageResourceData(d, customImageImport, customImage)
 	return nil
 }
 
+// verifyCustomImageChecksums compares the checksums the API computed for the
+// imported image with the expected ones, e.g. {sha256 = "..."}.
+func verifyCustomImageChecksums(expected map[string]any, actual map[string]string) error {
+	for _, algorithm := range slices.Sorted(maps.Keys(expected)) {
+		expectedChecksum := expected[algorithm].(string)
+		actualChecksum, ok := actual[algorithm]
+		if !ok {
+			return fmt.Errorf("the custom image has no %s checksum to verify, available are: %s", algorithm, strings.Join(slices.Sorted(maps.Keys(actual)), ", "))
+		}
+		if !strings.EqualFold(expectedChecksum, actualChecksum) {
+			return fmt.Errorf("%s checksum mismatch for the custom image: expected %s, got %s", algorithm, expectedChecksum, actualChecksum)
+		}
+	}
+	return nil
+}
+
+// verifyChangedCustomImageChecksums verifies expected_checksums that are
+// changed on an existing image against the checksums of that image. A new or
+// replaced image is verified after its import instead.
+func verifyChangedCustomImageChecksums(_ context.Context, d *schema.ResourceDiff, _ any) error {
+	if d.Id() == "" || !d.HasChange("expected_checksums") || !d.NewValueKnown("expected_checksums") {
+		return nil
+	}
+	if d.HasChanges("import_url", "import_source_format", "firmware_type") {
+		return nil
+	}
+	checksums := make(map[string]string)
+	for algorithm, checksum := range d.Get("checksums").(map[string]any) {
+		checksums[algorithm] = checksum.(string)
+	}
+	return verifyCustomImageChecksums(d.Get("expected_checksums").(map[string]any), checksums)
+}
+
 func fillCustomImageResourceData(d *schema.ResourceData, customImageImport *cloudscale.CustomImageImport, customImage *cloudscale.CustomImage) {
 	fillResourceData(d, gatherCustomImageResourceData(customImage))
 
@@ -204,22 +348,54 @@ func gatherCustomImageResourceData(customImage *cloudscale.CustomImage) Resource
 }
 
 func readCustomImage(ctx context.Context, rId GenericResourceIdentifier, meta any) (*cloudscale.CustomImage, error) {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.CustomImages.Get(ctx, rId.Id)
 }
 
-func updateCustomImage(ctx context.Context, rId GenericResourceIdentifier, meta any, updateRequest *cloudscale.CustomImageRequest) error {
-	client := meta.(*cloudscale.Client)
-	return client.CustomImages.Update(ctx, rId.Id, updateRequest)
+// customImageUpdateRequest is either a regular update or a change of the
+// zones the custom image is available in.
+type customImageUpdateRequest struct {
+	cloudscale.CustomImageRequest
+	Zones *customImageZonesRequest
 }
 
-func gatherCustomImageUpdateRequest(d *schema.ResourceData) []*cloudscale.CustomImageRequest {
-	requests := make([]*cloudscale.CustomImageRequest, 0)
+// customImageZonesRequest changes the zones of a custom image, which
+// cloudscale.CustomImageRequest doesn't support.
+type customImageZonesRequest struct {
+	Zones []string `json:"zones"`
+}
+
+func updateCustomImage(ctx context.Context, rId GenericResourceIdentifier, meta any, updateRequest *customImageUpdateRequest) error {
+	client := meta.(*providerMeta).Client
+	if updateRequest.Zones == nil {
+		return client.CustomImages.Update(ctx, rId.Id, &updateRequest.CustomImageRequest)
+	}
+
+	req, err := client.NewRequest(ctx, http.MethodPatch, "v1/custom-images/"+rId.Id, updateRequest.Zones)
+	if err != nil {
+		return err
+	}
+	return client.Do(ctx, req, nil)
+}
+
+func gatherCustomImageUpdateRequest(d *schema.ResourceData) []*customImageUpdateRequest {
+	requests := make([]*customImageUpdateRequest, 0)
+
+	if d.HasChange("zone_slugs") {
+		log.Printf("[INFO] Attribute zone_slugs changed")
+		zones := make([]string, 0)
+		for _, zone := range d.Get("zone_slugs").(*schema.Set).List() {
+			zones = append(zones, zone.(string))
+		}
+		requests = append(requests, &customImageUpdateRequest{
+			Zones: &customImageZonesRequest{Zones: zones},
+		})
+	}
 
 	for _, attribute := range []string{"name", "slug", "user_data_handling", "tags"} {
 		if d.HasChange(attribute) {
 			log.Printf("[INFO] Attribute %s changed", attribute)
-			opts := &cloudscale.CustomImageRequest{}
+			opts := &customImageUpdateRequest{}
 			requests = append(requests, opts)
 
 			if attribute == "name" {
@@ -236,8 +412,78 @@ func gatherCustomImageUpdateRequest(d *schema.ResourceData) []*cloudscale.Custom
 	return requests
 }
 
+// planCustomImageZones replaces the custom image instead of updating it, if it
+// is removed from a zone in which servers still use it. The API refuses such
+// a removal.
+func planCustomImageZones(ctx context.Context, d *schema.ResourceDiff, meta any) error {
+	if d.Id() == "" || !d.HasChange("zone_slugs") || !d.NewValueKnown("zone_slugs") {
+		return nil
+	}
+	o, n := d.GetChange("zone_slugs")
+	removed := o.(*schema.Set).Difference(n.(*schema.Set))
+	if removed.Len() == 0 {
+		return nil
+	}
+
+	slug, _ := d.GetChange("slug")
+	client := meta.(*providerMeta).Client
+	zones, err := customImageZonesInUse(ctx, client, d.Id(), slug.(string))
+	if err != nil {
+		return fmt.Errorf("error checking which servers use the custom image (%s): %s", d.Id(), err)
+	}
+	for _, zone := range removed.List() {
+		if slices.Contains(zones, zone.(string)) {
+			log.Printf("[INFO] Custom image %s is used by servers in %s, replacing it", d.Id(), zone)
+			return d.ForceNew("zone_slugs")
+		}
+	}
+	return nil
+}
+
+// customImageZonesInUse returns the zones with servers that may have been
+// created from the custom image. Servers refer to custom images by UUID if
+// the API reports it, or as "custom:<slug>". Without a slug the custom image
+// can't be told apart from others, so every server using a custom image
+// counts rather than guessing by name.
+func customImageZonesInUse(ctx context.Context, client *cloudscale.Client, uuid string, slug string) ([]string, error) {
+	var servers []struct {
+		Zone struct {
+			Slug string `json:"slug"`
+		} `json:"zone"`
+		Image struct {
+			UUID string `json:"uuid"`
+			Slug string `json:"slug"`
+		} `json:"image"`
+	}
+	if err := getFromAPI(ctx, client, "v1/servers", &servers); err != nil {
+		return nil, err
+	}
+
+	var zones []string
+	for _, server := range servers {
+		if !strings.HasPrefix(server.Image.Slug, "custom:") {
+			continue
+		}
+		switch {
+		case server.Image.UUID != "":
+			if server.Image.UUID != uuid {
+				continue
+			}
+		case server.Image.Slug == "custom:"+uuid:
+		case slug != "":
+			if server.Image.Slug != "custom:"+slug {
+				continue
+			}
+		}
+		if !slices.Contains(zones, server.Zone.Slug) {
+			zones = append(zones, server.Zone.Slug)
+		}
+	}
+	return zones, nil
+}
+
 func deleteCustomImage(ctx context.Context, rId GenericResourceIdentifier, meta any) error {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.CustomImages.Delete(ctx, rId.Id)
 }
 
@@ -260,7 +506,7 @@ func waitForCustomImageImportStatus(ctx context.Context, uuid string, d *schema.
 }
 
 func newCustomImageImportRefreshFunc(ctx context.Context, uuid string, d *schema.ResourceData, attribute string, meta any) resource.StateRefreshFunc {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return func() (any, string, error) {
 		customImageImport, err := client.CustomImageImports.Get(ctx, uuid)
 		if err != nil {
@@ -270,9 +516,48 @@ func newCustomImageImportRefreshFunc(ctx context.Context, uuid string, d *schema
 		log.Printf("[INFO] Status is %s", customImageImport.Status)
 
 		if customImageImport.Status == "failed" {
-			return nil, "", fmt.Errorf("CustomImageImport status %s, abort", customImageImport.Status)
+			return nil, "", &customImageImportFailedError{
+				UUID:         uuid,
+				ErrorMessage: customImageImport.ErrorMessage,
+			}
 		}
 
 		return customImageImport, customImageImport.Status, nil
 	}
 }
+
+// customImageImportFailedError is returned when the API reports that an import
+// failed, as opposed to errors while waiting for it.
+type customImageImportFailedError struct {
+	UUID         string
+	ErrorMessage string
+}
+
+func (e *customImageImportFailedError) Error() string {
+	if e.ErrorMessage == "" {
+		return fmt.Sprintf("custom image import (%s) failed", e.UUID)
+	}
+	return fmt.Sprintf("custom image import (%s) failed: %s", e.UUID, e.ErrorMessage)
+}
+
+// transientCustomImageImportFailure matches error messages of imports that
+// may succeed when retried: the download timed out, the connection broke
+// off or the server answered with a 5xx status.
+var transientCustomImageImportFailure = regexp.MustCompile(
+	`(?i)timed out|timeout|connection (reset|refused|aborted|closed)|temporar(y|ily)|` +
+		`(http|status|error)\D{0,10}5\d\d\b|\b5\d\d (internal server error|bad gateway|service unavailable|gateway time-?out)`)
+
+// Retryable reports whether importing again may succeed. Failures that aren't
+// known to be transient, e.g. an unsupported format, a 404 on the URL or an
+// empty message, fail the same way again and are not retried.
+func (e *customImageImportFailedError) Retryable() bool {
+	return transientCustomImageImportFailure.MatchString(e.ErrorMessage)
+}
+
+func deleteFailedCustomImage(ctx context.Context, client *cloudscale.Client, uuid string) error {
+	err := client.CustomImages.Delete(ctx, uuid)
+	if cerr, ok := errors.AsType[*cloudscale.ErrorResponse](err); ok && cerr.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
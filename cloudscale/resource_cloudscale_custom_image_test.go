@@ -7,6 +7,8 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"reflect"
+	"regexp"
 	"strings"
 	"testing"
 
@@ -37,7 +39,7 @@ func testSweepCustomImages(region string) error {
 		return err
 	}
 
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 
 	customImages, err := client.CustomImages.List(context.Background())
 	if err != nil {
@@ -103,6 +105,13 @@ func TestAccCloudscaleCustomImage_Import(t *testing.T) {
 						"cloudscale_custom_image.basic", "checksums.sha256", sha256sum),
 				),
 			},
+			{
+				ResourceName:      "cloudscale_custom_image.basic",
+				ImportState:       true,
+				ImportStateVerify: true,
+				// The API doesn't return the source format of an import.
+				ImportStateVerifyIgnore: []string{"import_source_format"},
+			},
 		},
 	})
 }
@@ -312,9 +321,263 @@ func TestAccCloudscaleCustomImage_Boot(t *testing.T) {
 	})
 }
 
+func TestVerifyCustomImageChecksums(t *testing.T) {
+	actual := map[string]string{"md5": "abc", "sha256": "def"}
+
+	for _, expected := range []map[string]any{
+		{},
+		{"sha256": "def"},
+		{"sha256": "DEF", "md5": "abc"},
+	} {
+		if err := verifyCustomImageChecksums(expected, actual); err != nil {
+			t.Errorf("%v: unexpected error: %s", expected, err)
+		}
+	}
+	for _, expected := range []map[string]any{
+		{"sha256": "xyz"},
+		{"md5": "abc", "sha256": "xyz"},
+		{"sha512": "def"},
+	} {
+		if err := verifyCustomImageChecksums(expected, actual); err == nil {
+			t.Errorf("%v: expected an error", expected)
+		}
+	}
+}
+
+func TestAccCloudscaleCustomImage_ExpectedChecksums(t *testing.T) {
+	var customImage cloudscale.CustomImage
+
+	rInt := acctest.RandInt()
+	sha256sum := getExpectedChecksum(smallImageRAWDownloadURL, "sha256", t)
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudscaleCustomImageDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config:      customImageConfig_expectedChecksum(rInt, strings.Repeat("0", 64)),
+				ExpectError: regexp.MustCompile(`sha256 checksum mismatch for the custom image.*the custom image was deleted`),
+			},
+			{
+				Config: customImageConfig_expectedChecksum(rInt, sha256sum),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudscaleCustomImageExists("cloudscale_custom_image.basic", &customImage),
+					resource.TestCheckResourceAttr(
+						"cloudscale_custom_image.basic", "expected_checksums.sha256", sha256sum),
+					resource.TestCheckResourceAttr(
+						"cloudscale_custom_image.basic", "checksums.sha256", sha256sum),
+				),
+			},
+			{
+				// Changed checksums are verified against the existing image.
+				Config:      customImageConfig_expectedChecksum(rInt, strings.Repeat("1", 64)),
+				ExpectError: regexp.MustCompile(`sha256 checksum mismatch for the custom image`),
+			},
+		},
+	})
+}
+
+func TestCustomImageImportRefreshFunc_Failed(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/custom-images/import/import-uuid", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"uuid": "import-uuid", "status": "failed", "error_message": "Download timed out."}`)
+	})
+	client := testClient(t, mux)
+
+	refresh := newCustomImageImportRefreshFunc(context.Background(), "import-uuid", nil, "import_status", client)
+	_, _, err := refresh()
+	importErr, ok := errors.AsType[*customImageImportFailedError](err)
+	if !ok {
+		t.Fatalf("got %v, want a failed import", err)
+	}
+	if importErr.ErrorMessage != "Download timed out." {
+		t.Errorf("got error message %q", importErr.ErrorMessage)
+	}
+	if expected := "custom image import (import-uuid) failed: Download timed out."; err.Error() != expected {
+		t.Errorf("got %q, want %q", err, expected)
+	}
+}
+
+func TestCustomImageImportFailedErrorRetryable(t *testing.T) {
+	for _, tc := range []struct {
+		errorMessage string
+		expected     bool
+	}{
+		{"Download timed out.", true},
+		{"Read timeout while downloading.", true},
+		{"Connection reset by peer.", true},
+		{"Download failed: 503 Service Unavailable", true},
+		{"Download failed with HTTP status 502.", true},
+		{"", false},
+		{"Download failed: 404 Not Found", false},
+		{"Download failed with status 403.", false},
+		{"Checksum mismatch.", false},
+		{"Unsupported image format.", false},
+		{"Invalid image: 400 MB of 512 MB downloaded from port 443.", false},
+		{"Image of 500 MB is too small.", false},
+	} {
+		err := &customImageImportFailedError{UUID: "import-uuid", ErrorMessage: tc.errorMessage}
+		if actual := err.Retryable(); actual != tc.expected {
+			t.Errorf("Retryable() for %q: got %t, want %t", tc.errorMessage, actual, tc.expected)
+		}
+	}
+}
+
+func TestDeleteFailedCustomImage(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/custom-images/gone", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("/v1/custom-images/broken", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	client := testClient(t, mux)
+
+	if err := deleteFailedCustomImage(context.Background(), client.Client, "gone"); err != nil {
+		t.Errorf("unexpected error for an image that is already gone: %s", err)
+	}
+	if err := deleteFailedCustomImage(context.Background(), client.Client, "broken"); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestAccCloudscaleCustomImage_ImportFailed(t *testing.T) {
+	rInt := acctest.RandInt()
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudscaleCustomImageDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config:      customImageConfig_failing(rInt),
+				ExpectError: regexp.MustCompile(`custom image import \(.*\) failed.*\(attempt 1 of 2, not retried\)`),
+			},
+		},
+	})
+}
+
+func TestCustomImageZonesInUse(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/servers", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `[
+			{"zone": {"slug": "lpg1"}, "image": {"slug": "custom:golden", "name": "Golden"}},
+			{"zone": {"slug": "lpg1"}, "image": {"slug": "custom:golden", "name": "Golden"}},
+			{"zone": {"slug": "rma1"}, "image": {"slug": "custom:other", "name": "Other"}},
+			{"zone": {"slug": "rma1"}, "image": {"slug": "debian-13", "name": "Golden"}},
+			{"zone": {"slug": "rma1"}, "image": {"uuid": "renamed-uuid", "slug": "custom:renamed", "name": "Renamed"}}
+		]`)
+	})
+	client := testClient(t, mux)
+
+	for _, tc := range []struct {
+		uuid, slug string
+		expected   []string
+	}{
+		{"golden-uuid", "golden", []string{"lpg1"}},
+		{"unused-uuid", "unused", nil},
+		{"renamed-uuid", "new-slug", []string{"rma1"}},
+		// Without a slug, every server using a custom image may use this one.
+		{"nameless-uuid", "", []string{"lpg1", "rma1"}},
+	} {
+		zones, err := customImageZonesInUse(context.Background(), client.Client, tc.uuid, tc.slug)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if !reflect.DeepEqual(zones, tc.expected) {
+			t.Errorf("%s/%s: got %v, want %v", tc.uuid, tc.slug, zones, tc.expected)
+		}
+	}
+}
+
+func TestAccCloudscaleCustomImage_UpdateZones(t *testing.T) {
+	var beforeUpdate, afterAdd, afterRemove cloudscale.CustomImage
+
+	rInt := acctest.RandInt()
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudscaleCustomImageDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: customImageConfig_zones(rInt, `"lpg1"`),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudscaleCustomImageExists("cloudscale_custom_image.basic", &beforeUpdate),
+					resource.TestCheckResourceAttr(
+						"cloudscale_custom_image.basic", "zone_slugs.#", "1"),
+				),
+			},
+			{
+				Config: customImageConfig_zones(rInt, `"lpg1", "rma1"`),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudscaleCustomImageExists("cloudscale_custom_image.basic", &afterAdd),
+					testAccCheckCustomImageIsSame(&beforeUpdate, &afterAdd),
+					resource.TestCheckResourceAttr(
+						"cloudscale_custom_image.basic", "zone_slugs.#", "2"),
+					resource.TestCheckTypeSetElemAttr(
+						"cloudscale_custom_image.basic", "zone_slugs.*", "rma1"),
+				),
+			},
+			{
+				Config: customImageConfig_zones(rInt, `"rma1"`),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudscaleCustomImageExists("cloudscale_custom_image.basic", &afterRemove),
+					testAccCheckCustomImageIsSame(&beforeUpdate, &afterRemove),
+					resource.TestCheckResourceAttr(
+						"cloudscale_custom_image.basic", "zone_slugs.#", "1"),
+					resource.TestCheckTypeSetElemAttr(
+						"cloudscale_custom_image.basic", "zone_slugs.*", "rma1"),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckCustomImageIsSame(before, after *cloudscale.CustomImage) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		if before.UUID != after.UUID {
+			return fmt.Errorf("expected the custom image %s to be updated, got %s", before.UUID, after.UUID)
+		}
+		return nil
+	}
+}
+
+func TestFindCustomImageImport(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/custom-images/import", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `[
+			{"uuid": "first-import", "custom_image": {"uuid": "image-a"}, "url": "https://example.com/a.raw"},
+			{"uuid": "other-import", "custom_image": {"uuid": "image-b"}, "url": "https://example.com/b.raw"},
+			{"uuid": "second-import", "custom_image": {"uuid": "image-a"}, "url": "https://example.com/a2.raw"}
+		]`)
+	})
+	client := testClient(t, mux)
+
+	found, err := findCustomImageImport(context.Background(), client.Client, "image-a")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if found == nil || found.UUID != "second-import" || found.URL != "https://example.com/a2.raw" {
+		t.Errorf("got %+v, want the second import of image-a", found)
+	}
+
+	found, err = findCustomImageImport(context.Background(), client.Client, "image-c")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if found != nil {
+		t.Errorf("got %+v, want no import", found)
+	}
+}
+
 func testAccCheckCloudscaleCustomImageImportExistsForImage(image *cloudscale.CustomImage, imageImport *cloudscale.CustomImageImport) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
-		client := testAccProvider.Meta().(*cloudscale.Client)
+		client := testAccProvider.Meta().(*providerMeta).Client
 		imports, err := client.CustomImageImports.List(context.Background())
 		if err != nil {
 			return err
@@ -331,7 +594,7 @@ func testAccCheckCloudscaleCustomImageImportExistsForImage(image *cloudscale.Cus
 }
 
 func testAccCheckCloudscaleCustomImageDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*cloudscale.Client)
+	client := testAccProvider.Meta().(*providerMeta).Client
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "cloudscale_custom_image" {
@@ -375,6 +638,40 @@ resource "cloudscale_custom_image" "%s" {
 }`, name, imageDownloadURL, importSourceFormatLine, rInt)
 }
 
+func customImageConfig_expectedChecksum(rInt int, sha256sum string) string {
+	return fmt.Sprintf(`
+resource "cloudscale_custom_image" "basic" {
+  import_url         = "%s"
+  name               = "terraform-%d"
+  user_data_handling = "extend-cloud-config"
+  zone_slugs         = ["lpg1"]
+  expected_checksums = {
+    sha256 = "%s"
+  }
+}`, smallImageRAWDownloadURL, rInt, sha256sum)
+}
+
+func customImageConfig_failing(rInt int) string {
+	return fmt.Sprintf(`
+resource "cloudscale_custom_image" "basic" {
+  import_url         = "https://at-images.objects.lpg.cloudscale.ch/prod/does-not-exist.raw"
+  name               = "terraform-%d"
+  user_data_handling = "extend-cloud-config"
+  zone_slugs         = ["lpg1"]
+  import_retries     = 1
+}`, rInt)
+}
+
+func customImageConfig_zones(rInt int, zoneSlugs string) string {
+	return fmt.Sprintf(`
+resource "cloudscale_custom_image" "basic" {
+  import_url         = "%s"
+  name               = "terraform-%d"
+  user_data_handling = "extend-cloud-config"
+  zone_slugs         = [%s]
+}`, smallImageRAWDownloadURL, rInt, zoneSlugs)
+}
+
 func customImageConfig_tags(name string, imageDownloadURL string, rInt int, importSourceFormat *string) string {
 	importSourceFormatLine := ""
 	if importSourceFormat != nil {
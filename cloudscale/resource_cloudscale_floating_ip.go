@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/http"
 
 	"github.com/cloudscale-ch/cloudscale-go-sdk/v10"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
@@ -12,11 +13,21 @@ import (
 
 const floatingIPHumanName = "Floating IP"
 
+// floatingIPLockKey serializes the changes of a floating IP's target, which
+// can be made by both the floating IP and its association.
+func floatingIPLockKey(ip string) string {
+	return fmt.Sprintf("cloudscale/floating-ip/%s", ip)
+}
+
+func lockKeyFromFloatingIPID(_ context.Context, d *schema.ResourceData, _ any) (string, error) {
+	return floatingIPLockKey(d.Id()), nil
+}
+
 var (
 	resourceFloatingIPCreate = getCreateOperation(createFloatingIP, nil)
 	resourceFloatingIPRead   = getReadOperation(floatingIPHumanName, getGenericResourceIdentifierFromSchema, readFloatingIP, gatherFloatingIPResourceData)
-	resourceFloatingIPUpdate = getUpdateOperation(floatingIPHumanName, getGenericResourceIdentifierFromSchema, updateFloatingIP, resourceFloatingIPRead, gatherFloatingIPUpdateRequest, nil)
-	resourceFloatingIPDelete = getDeleteOperation(floatingIPHumanName, getGenericResourceIdentifierFromSchema, deleteFloatingIP, nil)
+	resourceFloatingIPUpdate = getUpdateOperation(floatingIPHumanName, getGenericResourceIdentifierFromSchema, updateFloatingIP, resourceFloatingIPRead, gatherFloatingIPUpdateRequest, lockKeyFromFloatingIPID)
+	resourceFloatingIPDelete = getDeleteOperation(floatingIPHumanName, getGenericResourceIdentifierFromSchema, deleteFloatingIP, lockKeyFromFloatingIPID)
 )
 
 func resourceCloudscaleFloatingIP() *schema.Resource {
@@ -29,11 +40,19 @@ func resourceCloudscaleFloatingIP() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
-		Schema: getFloatingIPSchema(RESOURCE),
+		Schema:        getFloatingIPSchema(RESOURCE),
+		CustomizeDiff: planFloatingIPTarget,
 	}
 }
 
 func getFloatingIPSchema(t SchemaType) map[string]*schema.Schema {
+	serverConflictsWith := []string{}
+	loadBalancerConflictsWith := []string{}
+	if t.isResource() {
+		// A floating IP points at one target at a time.
+		serverConflictsWith = append(serverConflictsWith, "load_balancer")
+		loadBalancerConflictsWith = append(loadBalancerConflictsWith, "server")
+	}
 	m := map[string]*schema.Schema{
 		"ip_version": {
 			Type:     schema.TypeInt,
@@ -41,15 +60,19 @@ func getFloatingIPSchema(t SchemaType) map[string]*schema.Schema {
 			Optional: t.isDataSource(),
 			ForceNew: true,
 		},
+		// The target is computed, so that it can be managed elsewhere, e.g. by
+		// a cloudscale_floating_ip_association. See planFloatingIPTarget.
 		"server": {
-			Type:     schema.TypeString,
-			Optional: t.isResource(),
-			Computed: t.isDataSource(),
+			Type:          schema.TypeString,
+			Optional:      t.isResource(),
+			Computed:      true,
+			ConflictsWith: serverConflictsWith,
 		},
 		"load_balancer": {
-			Type:     schema.TypeString,
-			Optional: t.isResource(),
-			Computed: t.isDataSource(),
+			Type:          schema.TypeString,
+			Optional:      t.isResource(),
+			Computed:      true,
+			ConflictsWith: loadBalancerConflictsWith,
 		},
 		"region_slug": {
 			Type:     schema.TypeString,
@@ -99,7 +122,7 @@ func getFloatingIPSchema(t SchemaType) map[string]*schema.Schema {
 }
 
 func createFloatingIP(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 
 	opts := &cloudscale.FloatingIPCreateRequest{
 		IPVersion: d.Get("ip_version").(int),
@@ -141,6 +164,41 @@ func createFloatingIP(ctx context.Context, d *schema.ResourceData, meta any) dia
 	return resourceFloatingIPRead(ctx, d, meta)
 }
 
+// planFloatingIPTarget plans the changes of the target that Terraform can't
+// tell from the computed server and load_balancer: a target left out of the
+// configuration keeps its current value, whoever assigned it, but an empty
+// one unassigns the Floating IP. Setting one target clears the other, which
+// moves the Floating IP.
+func planFloatingIPTarget(_ context.Context, d *schema.ResourceDiff, _ any) error {
+	config := d.GetRawConfig()
+	if config.IsNull() || !config.IsKnown() {
+		return nil
+	}
+	for _, attrs := range [][2]string{{"server", "load_balancer"}, {"load_balancer", "server"}} {
+		key, other := attrs[0], attrs[1]
+		value := config.GetAttr(key)
+		if value.IsNull() {
+			continue
+		}
+		oldValue, _ := d.GetChange(key)
+		oldOther, _ := d.GetChange(other)
+		if value.IsKnown() && value.AsString() == "" {
+			if oldValue.(string) != "" {
+				if err := d.SetNew(key, ""); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if config.GetAttr(other).IsNull() && oldOther.(string) != "" {
+			if err := d.SetNew(other, ""); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func gatherFloatingIPResourceData(floatingIP *cloudscale.FloatingIP) ResourceDataRaw {
 	m := make(map[string]any)
 	m["id"] = floatingIP.IP()
@@ -170,37 +228,76 @@ func gatherFloatingIPResourceData(floatingIP *cloudscale.FloatingIP) ResourceDat
 }
 
 func readFloatingIP(ctx context.Context, rId GenericResourceIdentifier, meta any) (*cloudscale.FloatingIP, error) {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.FloatingIPs.Get(ctx, rId.Id)
 }
 
-func updateFloatingIP(ctx context.Context, rId GenericResourceIdentifier, meta any, updateRequest *cloudscale.FloatingIPUpdateRequest) error {
-	client := meta.(*cloudscale.Client)
-	return client.FloatingIPs.Update(ctx, rId.Id, updateRequest)
+// floatingIPUpdateRequest is either a regular update or a change of the
+// target the floating IP points at.
+type floatingIPUpdateRequest struct {
+	cloudscale.FloatingIPUpdateRequest
+	Target *floatingIPTargetRequest
+}
+
+// floatingIPTargetRequest sets both targets of a floating IP in one request.
+// cloudscale.FloatingIPUpdateRequest omits empty targets, so it can neither
+// unassign a floating IP nor move it from a server to a load balancer
+// atomically. Here the unused target is sent as null, which clears it.
+type floatingIPTargetRequest struct {
+	Server       *string `json:"server"`
+	LoadBalancer *string `json:"load_balancer"`
+}
+
+func newFloatingIPTargetRequest(server string, loadBalancer string) *floatingIPTargetRequest {
+	request := &floatingIPTargetRequest{}
+	if server != "" {
+		request.Server = &server
+	}
+	if loadBalancer != "" {
+		request.LoadBalancer = &loadBalancer
+	}
+	return request
+}
+
+func updateFloatingIP(ctx context.Context, rId GenericResourceIdentifier, meta any, updateRequest *floatingIPUpdateRequest) error {
+	client := meta.(*providerMeta).Client
+	if updateRequest.Target == nil {
+		return client.FloatingIPs.Update(ctx, rId.Id, &updateRequest.FloatingIPUpdateRequest)
+	}
+
+	req, err := client.NewRequest(ctx, http.MethodPatch, "v1/floating-ips/"+rId.Id, updateRequest.Target)
+	if err != nil {
+		return err
+	}
+	return client.Do(ctx, req, nil)
 }
 
-func gatherFloatingIPUpdateRequest(d *schema.ResourceData) []*cloudscale.FloatingIPUpdateRequest {
-	requests := make([]*cloudscale.FloatingIPUpdateRequest, 0)
+func gatherFloatingIPUpdateRequest(d *schema.ResourceData) []*floatingIPUpdateRequest {
+	requests := make([]*floatingIPUpdateRequest, 0)
 
-	for _, attribute := range []string{"server", "load_balancer", "tags", "reverse_ptr"} {
+	if d.HasChanges("server", "load_balancer") {
+		serverUUID := d.Get("server").(string)
+		loadBalancerUUID := d.Get("load_balancer").(string)
+		if serverUUID != "" {
+			log.Printf("[INFO] Assigning the Floating IP %s to the Server %s", d.Id(), serverUUID)
+		} else if loadBalancerUUID != "" {
+			log.Printf("[INFO] Assigning the Floating IP %s to the LB %s", d.Id(), loadBalancerUUID)
+		} else {
+			log.Printf("[INFO] Unassigning the Floating IP %s", d.Id())
+		}
+		requests = append(requests, &floatingIPUpdateRequest{
+			Target: newFloatingIPTargetRequest(serverUUID, loadBalancerUUID),
+		})
+	}
+
+	for _, attribute := range []string{"tags", "reverse_ptr"} {
 		if d.HasChange(attribute) {
 			log.Printf("[INFO] Attribute %s changed", attribute)
-			opts := &cloudscale.FloatingIPUpdateRequest{}
+			opts := &floatingIPUpdateRequest{}
 			requests = append(requests, opts)
 
 			if attribute == "reverse_ptr" {
 				opts.ReversePointer = d.Get(attribute).(string)
-			} else if attribute == "server" || attribute == "load_balancer" {
-				serverUUID := d.Get("server").(string)
-				if serverUUID != "" {
-					log.Printf("[INFO] Assigning the Floating IP %s to the Server %s", d.Id(), serverUUID)
-					opts.Server = serverUUID
-				}
-				loadBalancerUUID := d.Get("load_balancer").(string)
-				if loadBalancerUUID != "" {
-					log.Printf("[INFO] Assigning the Floating IP %s to the LB %s", d.Id(), loadBalancerUUID)
-					opts.LoadBalancer = loadBalancerUUID
-				}
 			} else if attribute == "tags" {
 				opts.Tags = TagsFromState(d)
 			}
@@ -210,6 +307,6 @@ func gatherFloatingIPUpdateRequest(d *schema.ResourceData) []*cloudscale.Floatin
 }
 
 func deleteFloatingIP(ctx context.Context, rId GenericResourceIdentifier, meta any) error {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.FloatingIPs.Delete(ctx, rId.Id)
 }
@@ -0,0 +1,130 @@
+package cloudscale
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/cloudscale-ch/cloudscale-go-sdk/v10"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+const floatingIPAssociationHumanName = "Floating IP association"
+
+// floatingIPAssociationLockKey serializes association operations with the
+// updates of the floating IP itself.
+var floatingIPAssociationLockKey = uuidLockKey("floating_ip", floatingIPLockKey)
+
+var (
+	resourceCloudscaleFloatingIPAssociationCreate = getCreateOperation(createFloatingIPAssociation, floatingIPAssociationLockKey)
+	resourceCloudscaleFloatingIPAssociationRead   = getReadOperation(floatingIPAssociationHumanName, getGenericResourceIdentifierFromSchema, readFloatingIPAssociation, gatherFloatingIPAssociationResourceData)
+	resourceCloudscaleFloatingIPAssociationUpdate = getUpdateOperation(floatingIPAssociationHumanName, getGenericResourceIdentifierFromSchema, updateFloatingIP, resourceCloudscaleFloatingIPAssociationRead, gatherFloatingIPAssociationUpdateRequest, floatingIPAssociationLockKey)
+	resourceCloudscaleFloatingIPAssociationDelete = getDeleteOperation(floatingIPAssociationHumanName, getGenericResourceIdentifierFromSchema, deleteFloatingIPAssociation, floatingIPAssociationLockKey)
+)
+
+// resourceCloudscaleFloatingIPAssociation points a floating IP at a server or
+// a load balancer. The association has no API object of its own: it is the
+// target of the floating IP, and the floating IP's ID is its ID.
+func resourceCloudscaleFloatingIPAssociation() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceCloudscaleFloatingIPAssociationCreate,
+		ReadContext:   resourceCloudscaleFloatingIPAssociationRead,
+		UpdateContext: resourceCloudscaleFloatingIPAssociationUpdate,
+		DeleteContext: resourceCloudscaleFloatingIPAssociationDelete,
+
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		Schema: getFloatingIPAssociationSchema(),
+	}
+}
+
+func getFloatingIPAssociationSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"floating_ip": {
+			Type:     schema.TypeString,
+			Required: true,
+			ForceNew: true,
+		},
+		"server": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			ExactlyOneOf: []string{"server", "load_balancer"},
+		},
+		"load_balancer": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			ExactlyOneOf: []string{"server", "load_balancer"},
+		},
+	}
+}
+
+func createFloatingIPAssociation(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+	floatingIP := d.Get("floating_ip").(string)
+	opts := &floatingIPUpdateRequest{
+		Target: newFloatingIPTargetRequest(d.Get("server").(string), d.Get("load_balancer").(string)),
+	}
+
+	log.Printf("[DEBUG] Floating IP association create configuration: %#v", opts.Target)
+
+	err := updateFloatingIP(ctx, GenericResourceIdentifier{Id: floatingIP}, meta, opts)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error associating Floating IP %s: %s", floatingIP, err))
+	}
+
+	d.SetId(floatingIP)
+
+	log.Printf("[INFO] Floating IP association ID %s", d.Id())
+	return resourceCloudscaleFloatingIPAssociationRead(ctx, d, meta)
+}
+
+func gatherFloatingIPAssociationResourceData(floatingIP *cloudscale.FloatingIP) ResourceDataRaw {
+	m := make(map[string]any)
+	m["id"] = floatingIP.IP()
+	m["floating_ip"] = floatingIP.IP()
+	if floatingIP.Server != nil {
+		m["server"] = floatingIP.Server.UUID
+	} else {
+		m["server"] = nil
+	}
+	if floatingIP.LoadBalancer != nil {
+		m["load_balancer"] = floatingIP.LoadBalancer.UUID
+	} else {
+		m["load_balancer"] = nil
+	}
+	return m
+}
+
+func readFloatingIPAssociation(ctx context.Context, rId GenericResourceIdentifier, meta any) (*cloudscale.FloatingIP, error) {
+	floatingIP, err := readFloatingIP(ctx, rId, meta)
+	if err != nil {
+		return nil, err
+	}
+	if floatingIP.Server == nil && floatingIP.LoadBalancer == nil {
+		// The floating IP was unassigned; signal a 404 so the association is
+		// removed from state via CheckDeleted.
+		return nil, &cloudscale.ErrorResponse{StatusCode: http.StatusNotFound}
+	}
+	return floatingIP, nil
+}
+
+func gatherFloatingIPAssociationUpdateRequest(d *schema.ResourceData) []*floatingIPUpdateRequest {
+	requests := make([]*floatingIPUpdateRequest, 0)
+
+	if d.HasChanges("server", "load_balancer") {
+		log.Printf("[INFO] Re-pointing the Floating IP %s", d.Id())
+		requests = append(requests, &floatingIPUpdateRequest{
+			Target: newFloatingIPTargetRequest(d.Get("server").(string), d.Get("load_balancer").(string)),
+		})
+	}
+	return requests
+}
+
+func deleteFloatingIPAssociation(ctx context.Context, rId GenericResourceIdentifier, meta any) error {
+	// Deleting the association unassigns the floating IP, which is kept.
+	return updateFloatingIP(ctx, rId, meta, &floatingIPUpdateRequest{
+		Target: newFloatingIPTargetRequest("", ""),
+	})
+}
@@ -0,0 +1,120 @@
+package cloudscale
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/cloudscale-ch/cloudscale-go-sdk/v10"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestReadFloatingIPAssociation_Unassigned(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/floating-ips/192.0.2.1", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"network": "192.0.2.1/32", "server": null, "load_balancer": null}`)
+	})
+	client := testClient(t, mux)
+
+	_, err := readFloatingIPAssociation(context.Background(), GenericResourceIdentifier{Id: "192.0.2.1"}, client)
+	if cerr, ok := errors.AsType[*cloudscale.ErrorResponse](err); !ok || cerr.StatusCode != http.StatusNotFound {
+		t.Errorf("got %v, want a not found error", err)
+	}
+}
+
+func TestDeleteFloatingIPAssociation(t *testing.T) {
+	var body []byte
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/floating-ips/192.0.2.1", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch {
+			t.Errorf("unexpected %s request", r.Method)
+		}
+		var err error
+		body, err = io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("reading request body: %s", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	client := testClient(t, mux)
+
+	if err := deleteFloatingIPAssociation(context.Background(), GenericResourceIdentifier{Id: "192.0.2.1"}, client); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if expected := `{"server":null,"load_balancer":null}`; strings.TrimSpace(string(body)) != expected {
+		t.Errorf("got %s, want %s", body, expected)
+	}
+}
+
+func TestAccCloudscaleFloatingIPAssociation_Basic(t *testing.T) {
+	var floatingIP cloudscale.FloatingIP
+	rInt1 := acctest.RandInt()
+	rInt2 := acctest.RandInt()
+	resourceName := "cloudscale_floating_ip_association.vip"
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudscaleFloatingIPDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: floatingIPAssociationConfig(rInt1, rInt2, "server", "cloudscale_server.minlpg.id"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair(
+						resourceName, "floating_ip", "cloudscale_floating_ip.lbfloating", "id"),
+					resource.TestCheckResourceAttrPair(
+						resourceName, "server", "cloudscale_server.minlpg", "id"),
+					resource.TestCheckResourceAttr(
+						resourceName, "load_balancer", ""),
+				),
+			},
+			{
+				ResourceName:      resourceName,
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				Config: floatingIPAssociationConfig(rInt1, rInt2, "load_balancer", "cloudscale_load_balancer.lb1.id"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						resourceName, "server", ""),
+					resource.TestCheckResourceAttrPair(
+						resourceName, "load_balancer", "cloudscale_load_balancer.lb1", "id"),
+				),
+			},
+			{
+				// Removing the association keeps the floating IP, unassigned.
+				Config: testAccCheckCloudscaleFloatingIPConfig_target(rInt1, rInt2, ""),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudscaleFloatingIPExists("cloudscale_floating_ip.lbfloating", &floatingIP),
+					testAccCheckFloatingIPUnassigned(&floatingIP),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckFloatingIPUnassigned(floatingIP *cloudscale.FloatingIP) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		if floatingIP.Server != nil || floatingIP.LoadBalancer != nil {
+			return fmt.Errorf("Floating IP %s is still assigned", floatingIP.IP())
+		}
+		return nil
+	}
+}
+
+func floatingIPAssociationConfig(rInt1, rInt2 int, target, targetID string) string {
+	return testAccCheckCloudscaleFloatingIPConfig_target(rInt1, rInt2, "") + fmt.Sprintf(`
+
+resource "cloudscale_floating_ip_association" "vip" {
+  floating_ip = cloudscale_floating_ip.lbfloating.id
+  %s = %s
+}`, target, targetID)
+}
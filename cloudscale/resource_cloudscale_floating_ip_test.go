@@ -2,6 +2,7 @@ package cloudscale
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
@@ -28,7 +29,7 @@ func testSweepFloatingIps(region string) error {
 		return err
 	}
 
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 
 	ips, err := client.FloatingIPs.List(context.Background())
 	if err != nil {
@@ -199,6 +200,58 @@ func TestAccCloudscaleFloatingIP_LoadBalancer(t *testing.T) {
 	})
 }
 
+func TestAccCloudscaleFloatingIP_Unassign(t *testing.T) {
+	var afterAssign, afterUnassign cloudscale.FloatingIP
+	rInt1 := acctest.RandInt()
+	rInt2 := acctest.RandInt()
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudscaleFloatingIPDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCheckCloudscaleFloatingIPConfig_target(rInt1, rInt2, "server"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudscaleFloatingIPExists("cloudscale_floating_ip.lbfloating", &afterAssign),
+					resource.TestCheckResourceAttrSet(
+						"cloudscale_floating_ip.lbfloating", "server"),
+				),
+			},
+			{
+				// Without a target in the configuration, the assignment is kept.
+				Config: testAccCheckCloudscaleFloatingIPConfig_target(rInt1, rInt2, ""),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet(
+						"cloudscale_floating_ip.lbfloating", "server"),
+				),
+			},
+			{
+				Config: testAccCheckCloudscaleFloatingIPConfig_target(rInt1, rInt2, "unassign"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudscaleFloatingIPExists("cloudscale_floating_ip.lbfloating", &afterUnassign),
+					testAccCheckFloatingIPIsSame(t, &afterAssign, &afterUnassign),
+					resource.TestCheckResourceAttr(
+						"cloudscale_floating_ip.lbfloating", "server", ""),
+					resource.TestCheckResourceAttr(
+						"cloudscale_floating_ip.lbfloating", "load_balancer", ""),
+					resource.TestCheckResourceAttr(
+						"cloudscale_floating_ip.lbfloating", "next_hop", ""),
+				),
+			},
+			{
+				Config: testAccCheckCloudscaleFloatingIPConfig_target(rInt1, rInt2, "load_balancer"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"cloudscale_floating_ip.lbfloating", "server", ""),
+					resource.TestCheckResourceAttrSet(
+						"cloudscale_floating_ip.lbfloating", "load_balancer"),
+				),
+			},
+		},
+	})
+}
+
 func TestAccCloudscaleFloatingIP_Update(t *testing.T) {
 	var beforeUpdate, afterUpdate cloudscale.FloatingIP
 	rIntA := acctest.RandInt()
@@ -316,7 +369,7 @@ func TestAccCloudscaleFloatingIP_tags(t *testing.T) {
 }
 
 func testAccCheckCloudscaleFloatingIPDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*cloudscale.Client)
+	client := testAccProvider.Meta().(*providerMeta).Client
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "cloudscale_floating_ip.gateway" {
@@ -480,9 +533,23 @@ resource "cloudscale_floating_ip" "minfloating" {
 }
 
 func testAccCheckCloudscaleFloatingIPConfig_lb_and_server(rInt1, rInt2 int, assignLB bool) string {
-	assignment := `load_balancer = "${cloudscale_load_balancer.lb1.id}"`
-	if !assignLB {
+	if assignLB {
+		return testAccCheckCloudscaleFloatingIPConfig_target(rInt1, rInt2, "load_balancer")
+	}
+	return testAccCheckCloudscaleFloatingIPConfig_target(rInt1, rInt2, "server")
+}
+
+// testAccCheckCloudscaleFloatingIPConfig_target assigns the floating IP to the
+// "server", the "load_balancer" or, given "", to nothing.
+func testAccCheckCloudscaleFloatingIPConfig_target(rInt1, rInt2 int, target string) string {
+	assignment := ""
+	switch target {
+	case "load_balancer":
+		assignment = `load_balancer = "${cloudscale_load_balancer.lb1.id}"`
+	case "server":
 		assignment = `server        = "${cloudscale_server.minlpg.id}"`
+	case "unassign":
+		assignment = `server        = ""`
 	}
 	return fmt.Sprintf(`
 resource "cloudscale_load_balancer" "lb1" {
@@ -505,3 +572,21 @@ resource "cloudscale_floating_ip" "lbfloating" {
   region_slug = "lpg"
 }`, rInt1, rInt2, DefaultImageSlug, assignment)
 }
+
+func TestNewFloatingIPTargetRequest(t *testing.T) {
+	for _, tc := range []struct {
+		server, loadBalancer, expected string
+	}{
+		{"", "", `{"server":null,"load_balancer":null}`},
+		{"server-uuid", "", `{"server":"server-uuid","load_balancer":null}`},
+		{"", "lb-uuid", `{"server":null,"load_balancer":"lb-uuid"}`},
+	} {
+		actual, err := json.Marshal(newFloatingIPTargetRequest(tc.server, tc.loadBalancer))
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if string(actual) != tc.expected {
+			t.Errorf("got %s, want %s", actual, tc.expected)
+		}
+	}
+}
@@ -23,6 +23,7 @@ var interfaceLockKey = uuidLockKey("router_uuid", routerLockKey)
 var (
 	resourceCloudscaleInterfaceCreate = getCreateOperation(createInterface, interfaceLockKey)
 	resourceCloudscaleInterfaceRead   = getReadOperation(interfaceHumanName, getInterfaceResourceIdentifierFromSchema, readInterface, gatherInterfaceResourceData)
+	resourceCloudscaleInterfaceUpdate = getUpdateOperation(interfaceHumanName, getInterfaceResourceIdentifierFromSchema, updateInterface, resourceCloudscaleInterfaceRead, gatherInterfaceUpdateRequest, interfaceLockKey)
 	resourceCloudscaleInterfaceDelete = getDeleteOperation(interfaceHumanName, getInterfaceResourceIdentifierFromSchema, deleteInterface, interfaceLockKey)
 )
 
@@ -35,6 +36,7 @@ func resourceCloudscaleInterface() *schema.Resource {
 	return &schema.Resource{
 		CreateContext: resourceCloudscaleInterfaceCreate,
 		ReadContext:   resourceCloudscaleInterfaceRead,
+		UpdateContext: resourceCloudscaleInterfaceUpdate,
 		DeleteContext: resourceCloudscaleInterfaceDelete,
 
 		Importer: &schema.ResourceImporter{
@@ -94,7 +96,6 @@ func getInterfaceSchema() map[string]*schema.Schema {
 		"addresses": {
 			Type:     schema.TypeList,
 			Required: true,
-			ForceNew: true,
 			Elem: &schema.Resource{
 				Schema: addressSchema(true),
 			},
@@ -111,7 +112,7 @@ func getInterfaceSchema() map[string]*schema.Schema {
 }
 
 func createInterface(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 
 	routerUUID := d.Get("router_uuid").(string)
 
@@ -119,14 +120,7 @@ func createInterface(ctx context.Context, d *schema.ResourceData, meta any) diag
 		Network: d.Get("network_uuid").(string),
 	}
 
-	for _, address := range d.Get("addresses").([]any) {
-		a := address.(map[string]any)
-		req := cloudscale.CreateAddressRequest{
-			Subnet:  a["subnet_uuid"].(string),
-			Address: a["address"].(string),
-		}
-		opts.Addresses = append(opts.Addresses, req)
-	}
+	opts.Addresses = createInterfaceAddressOptions(d)
 
 	log.Printf("[DEBUG] Interface create configuration: %#v", opts)
 
@@ -148,6 +142,18 @@ func createInterface(ctx context.Context, d *schema.ResourceData, meta any) diag
 	return nil
 }
 
+func createInterfaceAddressOptions(d *schema.ResourceData) []cloudscale.CreateAddressRequest {
+	var result []cloudscale.CreateAddressRequest
+	for _, address := range d.Get("addresses").([]any) {
+		a := address.(map[string]any)
+		result = append(result, cloudscale.CreateAddressRequest{
+			Subnet:  a["subnet_uuid"].(string),
+			Address: a["address"].(string),
+		})
+	}
+	return result
+}
+
 func gatherInterfaceResourceData(iface *cloudscale.RouterInterface) ResourceDataRaw {
 	m := make(map[string]any)
 	m["id"] = iface.UUID
@@ -162,7 +168,7 @@ func gatherInterfaceResourceData(iface *cloudscale.RouterInterface) ResourceData
 }
 
 func readInterface(ctx context.Context, rId InterfaceResourceIdentifier, meta any) (*cloudscale.RouterInterface, error) {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 
 	// Router-backed read: there is no per-interface GET endpoint, so scan the
 	// parent router's interface list for the interface we manage.
@@ -182,7 +188,36 @@ func readInterface(ctx context.Context, rId InterfaceResourceIdentifier, meta an
 	return nil, &cloudscale.ErrorResponse{StatusCode: http.StatusNotFound}
 }
 
+// interfaceUpdateRequest changes the addresses of an interface in place. The
+// SDK has no request type for it, so it is sent with a request of our own.
+type interfaceUpdateRequest struct {
+	Addresses []cloudscale.CreateAddressRequest `json:"addresses"`
+}
+
+func gatherInterfaceUpdateRequest(d *schema.ResourceData) []*interfaceUpdateRequest {
+	requests := make([]*interfaceUpdateRequest, 0)
+
+	if d.HasChange("addresses") {
+		log.Printf("[INFO] Attribute addresses changed")
+		requests = append(requests, &interfaceUpdateRequest{
+			Addresses: createInterfaceAddressOptions(d),
+		})
+	}
+	return requests
+}
+
+func updateInterface(ctx context.Context, rId InterfaceResourceIdentifier, meta any, updateRequest *interfaceUpdateRequest) error {
+	client := meta.(*providerMeta).Client
+
+	path := fmt.Sprintf("v1/routers/%s/interfaces/%s", rId.RouterID, rId.Id)
+	req, err := client.NewRequest(ctx, http.MethodPatch, path, updateRequest)
+	if err != nil {
+		return err
+	}
+	return client.Do(ctx, req, nil)
+}
+
 func deleteInterface(ctx context.Context, rId InterfaceResourceIdentifier, meta any) error {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.Routers.DeleteInterface(ctx, rId.RouterID, rId.Id)
 }
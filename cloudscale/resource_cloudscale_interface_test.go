@@ -56,9 +56,8 @@ func TestAccCloudscaleInterface_Basic(t *testing.T) {
 			},
 			{
 				// Re-plan with the same config to prove the addresses list is
-				// stable: because addresses is Computed+ForceNew, an API that
-				// returned more addresses than configured would show a perpetual
-				// (replacing) diff here.
+				// stable: an API that returned more addresses than configured
+				// would show a perpetual diff here.
 				Config:             interfaceConfig_basic(rInt),
 				PlanOnly:           true,
 				ExpectNonEmptyPlan: false,
@@ -67,6 +66,43 @@ func TestAccCloudscaleInterface_Basic(t *testing.T) {
 	})
 }
 
+func TestAccCloudscaleInterface_UpdateAddress(t *testing.T) {
+	var afterCreate, afterUpdate cloudscale.RouterInterface
+
+	rInt := acctest.RandInt()
+	resourceName := "cloudscale_interface.basic"
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudscaleInterfaceDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: interfaceConfig_withAddress(rInt, "10.11.12.10"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudscaleInterfaceExists(resourceName, &afterCreate),
+					resource.TestCheckResourceAttr(
+						resourceName, "addresses.0.address", "10.11.12.10"),
+				),
+			},
+			{
+				Config: interfaceConfig_withAddress(rInt, "10.11.12.1"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudscaleInterfaceExists(resourceName, &afterUpdate),
+					resource.TestCheckResourceAttr(
+						resourceName, "addresses.0.address", "10.11.12.1"),
+					func(s *terraform.State) error {
+						if afterCreate.UUID != afterUpdate.UUID {
+							return fmt.Errorf("interface was replaced: %s != %s", afterCreate.UUID, afterUpdate.UUID)
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
 func TestAccCloudscaleInterface_import_basic(t *testing.T) {
 	var router cloudscale.Router
 	var iface cloudscale.RouterInterface
@@ -109,7 +145,7 @@ func testAccCheckCloudscaleInterfaceExists(n string, iface *cloudscale.RouterInt
 			return errors.New("no interface ID is set")
 		}
 
-		client := testAccProvider.Meta().(*cloudscale.Client)
+		client := testAccProvider.Meta().(*providerMeta).Client
 
 		routerID := rs.Primary.Attributes["router_uuid"]
 		router, err := client.Routers.Get(context.Background(), routerID)
@@ -129,7 +165,7 @@ func testAccCheckCloudscaleInterfaceExists(n string, iface *cloudscale.RouterInt
 }
 
 func testAccCheckCloudscaleInterfaceDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*cloudscale.Client)
+	client := testAccProvider.Meta().(*providerMeta).Client
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "cloudscale_interface" {
@@ -157,6 +193,10 @@ func testAccCheckCloudscaleInterfaceDestroy(s *terraform.State) error {
 }
 
 func interfaceConfig_basic(rInt int) string {
+	return interfaceConfig_withAddress(rInt, "10.11.12.10")
+}
+
+func interfaceConfig_withAddress(rInt int, address string) string {
 	return fmt.Sprintf(`
 resource "cloudscale_network" "basic" {
   name                    = "terraform-%d"
@@ -181,7 +221,7 @@ resource "cloudscale_interface" "basic" {
 
   addresses {
     subnet_uuid = cloudscale_subnet.basic.id
-    address     = "10.11.12.10"
+    address     = "%s"
   }
-}`, rInt, rInt)
+}`, rInt, rInt, address)
 }
@@ -31,7 +31,8 @@ func resourceCloudscaleLoadBalancer() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
-		Schema: getLoadBalancerSchema(RESOURCE),
+		Schema:        getLoadBalancerSchema(RESOURCE),
+		CustomizeDiff: validateLoadBalancerSlugs,
 	}
 }
 
@@ -108,7 +109,7 @@ func createLoadBalancer(ctx context.Context, d *schema.ResourceData, meta any) d
 	timeout := d.Timeout(schema.TimeoutCreate)
 	startTime := time.Now()
 
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 
 	opts := &cloudscale.LoadBalancerRequest{
 		ZonalResourceRequest: cloudscale.ZonalResourceRequest{
@@ -147,7 +148,7 @@ func createLoadBalancer(ctx context.Context, d *schema.ResourceData, meta any) d
 }
 
 func newLoadBalancerRefreshFunc(ctx context.Context, d *schema.ResourceData, attribute string, meta any) resource.StateRefreshFunc {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return func() (any, string, error) {
 		id := d.Id()
 
@@ -212,12 +213,12 @@ func gatherLoadBalancerResourceData(loadbalancer *cloudscale.LoadBalancer) Resou
 }
 
 func readLoadBalancer(ctx context.Context, rId GenericResourceIdentifier, meta any) (*cloudscale.LoadBalancer, error) {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.LoadBalancers.Get(ctx, rId.Id)
 }
 
 func updateLoadBalancer(ctx context.Context, rId GenericResourceIdentifier, meta any, updateRequest *cloudscale.LoadBalancerRequest) error {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.LoadBalancers.Update(ctx, rId.Id, updateRequest)
 }
 
@@ -241,6 +242,6 @@ func gatherLoadBalancerUpdateRequest(d *schema.ResourceData) []*cloudscale.LoadB
 }
 
 func deleteLoadBalancer(ctx context.Context, rId GenericResourceIdentifier, meta any) error {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.LoadBalancers.Delete(ctx, rId.Id)
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/cloudscale-ch/cloudscale-go-sdk/v10"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
@@ -12,13 +13,13 @@ import (
 
 const healthMonitorHumanName = "load balancer health monitor"
 
-// Health monitor operations serialize on the load balancer that owns the parent pool
-// via lockKeyFromPoolUUID.
+// Health monitor operations serialize on the load balancer that owns the parent pool,
+// resolved by loadBalancerUUIDFromStateOrPool, until it is running again.
 var (
-	resourceCloudscaleLoadBalancerHealthMonitorCreate = getCreateOperation(createLoadBalancerHealthMonitor, lockKeyFromPoolUUID)
-	resourceCloudscaleLoadBalancerHealthMonitorRead   = getReadOperation(healthMonitorHumanName, getGenericResourceIdentifierFromSchema, readLoadBalancerHealthMonitor, gatherLoadBalancerHealthMonitorResourceData)
-	resourceCloudscaleLoadBalancerHealthMonitorUpdate = getUpdateOperation(healthMonitorHumanName, getGenericResourceIdentifierFromSchema, updateLoadBalancerHealthMonitor, resourceCloudscaleLoadBalancerHealthMonitorRead, gatherLoadBalancerHealthMonitorUpdateRequests, lockKeyFromPoolUUID)
-	resourceCloudscaleLoadBalancerHealthMonitorDelete = getDeleteOperation(healthMonitorHumanName, getGenericResourceIdentifierFromSchema, deleteLoadBalancerHealthMonitor, lockKeyFromPoolUUID)
+	resourceCloudscaleLoadBalancerHealthMonitorCreate = lockLoadBalancerUntilRunning(loadBalancerUUIDFromStateOrPool, schema.TimeoutCreate, getCreateOperation(createLoadBalancerHealthMonitor, nil))
+	resourceCloudscaleLoadBalancerHealthMonitorRead   = readWithLoadBalancerUUID(getReadOperation(healthMonitorHumanName, getGenericResourceIdentifierFromSchema, readLoadBalancerHealthMonitor, gatherLoadBalancerHealthMonitorResourceData))
+	resourceCloudscaleLoadBalancerHealthMonitorUpdate = lockLoadBalancerUntilRunning(loadBalancerUUIDFromStateOrPool, schema.TimeoutUpdate, getUpdateOperation(healthMonitorHumanName, getGenericResourceIdentifierFromSchema, updateLoadBalancerHealthMonitor, resourceCloudscaleLoadBalancerHealthMonitorRead, gatherLoadBalancerHealthMonitorUpdateRequests, nil))
+	resourceCloudscaleLoadBalancerHealthMonitorDelete = lockLoadBalancerUntilRunning(loadBalancerUUIDFromStateOrPool, schema.TimeoutDelete, getDeleteOperation(healthMonitorHumanName, getGenericResourceIdentifierFromSchema, deleteLoadBalancerHealthMonitor, nil))
 )
 
 func resourceCloudscaleLoadBalancerHealthMonitor() *schema.Resource {
@@ -32,6 +33,11 @@ func resourceCloudscaleLoadBalancerHealthMonitor() *schema.Resource {
 			StateContext: schema.ImportStatePassthroughContext,
 		},
 		Schema: getLoadBalancerHealthMonitorSchema(RESOURCE),
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
 	}
 }
 
@@ -115,12 +121,17 @@ func getLoadBalancerHealthMonitorSchema(t SchemaType) map[string]*schema.Schema
 			Type:     schema.TypeString,
 			Optional: true,
 		}
+	} else {
+		m["load_balancer_uuid"] = &schema.Schema{
+			Type:     schema.TypeString,
+			Computed: true,
+		}
 	}
 	return m
 }
 
 func createLoadBalancerHealthMonitor(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 
 	opts := &cloudscale.LoadBalancerHealthMonitorRequest{
 		Pool: d.Get("pool_uuid").(string),
@@ -187,12 +198,12 @@ func getCodes(codes []any) []string {
 }
 
 func readLoadBalancerHealthMonitor(ctx context.Context, rId GenericResourceIdentifier, meta any) (*cloudscale.LoadBalancerHealthMonitor, error) {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.LoadBalancerHealthMonitors.Get(ctx, rId.Id)
 }
 
 func updateLoadBalancerHealthMonitor(ctx context.Context, rId GenericResourceIdentifier, meta any, updateRequest *cloudscale.LoadBalancerHealthMonitorRequest) error {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.LoadBalancerHealthMonitors.Update(ctx, rId.Id, updateRequest)
 }
 
@@ -273,6 +284,6 @@ func gatherLoadBalancerHealthMonitorResourceData(loadBalancerHealthMonitor *clou
 }
 
 func deleteLoadBalancerHealthMonitor(ctx context.Context, rId GenericResourceIdentifier, meta any) error {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.LoadBalancerHealthMonitors.Delete(ctx, rId.Id)
 }
@@ -66,6 +66,8 @@ func TestAccCloudscaleLoadBalancerHealthMonitor_Basic(t *testing.T) {
 						resourceName, "pool_name", &loadBalancerPool.Name),
 					resource.TestCheckResourceAttrPtr(
 						resourceName, "pool_href", &loadBalancerPool.HREF),
+					resource.TestCheckResourceAttrPtr(
+						resourceName, "load_balancer_uuid", &loadBalancer.UUID),
 				),
 			},
 		},
@@ -630,7 +632,7 @@ resource "cloudscale_load_balancer_health_monitor" "lb-health_monitor-acc-test"
 
 func waitForMonitorStatus(member *cloudscale.LoadBalancerPoolMember, status string) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
-		client := testAccProvider.Meta().(*cloudscale.Client)
+		client := testAccProvider.Meta().(*providerMeta).Client
 
 		var retrievedPoolMember *cloudscale.LoadBalancerPoolMember
 		var err error
@@ -664,6 +666,12 @@ func waitForMonitorStatus(member *cloudscale.LoadBalancerPoolMember, status stri
 }
 
 func testAccCloudscaleLoadBalancerPoolMemberConfig_server(rInt int) string {
+	return testAccCloudscaleLoadBalancerPoolMemberConfig_serverWith(rInt, "")
+}
+
+// testAccCloudscaleLoadBalancerPoolMemberConfig_serverWith adds the given
+// arguments to the pool member of a web server.
+func testAccCloudscaleLoadBalancerPoolMemberConfig_serverWith(rInt int, memberArgs string) string {
 	return fmt.Sprintf(`
 %[2]s
 
@@ -709,8 +717,9 @@ resource "cloudscale_load_balancer_pool_member" "lb-pool-member-acc-test" {
   name          = "terraform-%[1]d-lb-pool-member"
   pool_uuid     = cloudscale_load_balancer_pool.lb-pool-acc-test.id
   protocol_port = 80
-  address       = cloudscale_server.basic.interfaces[1].addresses.0.address
+  address       = one([for i in cloudscale_server.basic.interfaces : i.addresses[0].address if i.type == "private"])
   subnet_uuid   = cloudscale_subnet.lb-subnet.id
+  %[5]s
 }
-`, rInt, testAccCloudscaleLoadBalancerSubnet(rInt), DefaultImageSlug, TestAddress)
+`, rInt, testAccCloudscaleLoadBalancerSubnet(rInt), DefaultImageSlug, TestAddress, memberArgs)
 }
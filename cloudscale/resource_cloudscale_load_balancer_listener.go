@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/cloudscale-ch/cloudscale-go-sdk/v10"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
@@ -12,15 +13,16 @@ import (
 
 const listenerHumanName = "load balancer listener"
 
-// Listener operations serialize on the load balancer that owns the parent pool
-// via lockKeyFromPoolUUID. The API requires a pool on every listener today, so the
-// unlocked path is unreachable. When pool-less listeners land, the listener gains an
-// optional load_balancer_uuid (mutually exclusive with pool_uuid) to lock on instead.
+// Listener operations serialize on the load balancer that owns the parent pool,
+// resolved by loadBalancerUUIDFromPoolUUID, until it is running again. The API
+// requires a pool on every listener today, so the unlocked path is unreachable.
+// When pool-less listeners land, the listener gains an optional load_balancer_uuid
+// (mutually exclusive with pool_uuid) to lock on instead.
 var (
-	resourceCloudscaleLoadBalancerListenerCreate = getCreateOperation(createLoadBalancerListener, lockKeyFromPoolUUID)
+	resourceCloudscaleLoadBalancerListenerCreate = lockLoadBalancerUntilRunning(loadBalancerUUIDFromPoolUUID, schema.TimeoutCreate, getCreateOperation(createLoadBalancerListener, nil))
 	resourceCloudscaleLoadBalancerListenerRead   = getReadOperation(listenerHumanName, getGenericResourceIdentifierFromSchema, readLoadBalancerListener, gatherLoadBalancerListenerResourceData)
-	resourceCloudscaleLoadBalancerListenerUpdate = getUpdateOperation(listenerHumanName, getGenericResourceIdentifierFromSchema, updateLoadBalancerListener, resourceCloudscaleLoadBalancerListenerRead, gatherLoadBalancerListenerUpdateRequest, lockKeyFromPoolUUID)
-	resourceCloudscaleLoadBalancerListenerDelete = getDeleteOperation(listenerHumanName, getGenericResourceIdentifierFromSchema, deleteLoadBalancerListener, lockKeyFromPoolUUID)
+	resourceCloudscaleLoadBalancerListenerUpdate = lockLoadBalancerUntilRunning(loadBalancerUUIDFromPoolUUID, schema.TimeoutUpdate, getUpdateOperation(listenerHumanName, getGenericResourceIdentifierFromSchema, updateLoadBalancerListener, resourceCloudscaleLoadBalancerListenerRead, gatherLoadBalancerListenerUpdateRequest, nil))
+	resourceCloudscaleLoadBalancerListenerDelete = lockLoadBalancerUntilRunning(loadBalancerUUIDFromPoolUUID, schema.TimeoutDelete, getDeleteOperation(listenerHumanName, getGenericResourceIdentifierFromSchema, deleteLoadBalancerListener, nil))
 )
 
 func resourceCloudscaleLoadBalancerListener() *schema.Resource {
@@ -34,6 +36,11 @@ func resourceCloudscaleLoadBalancerListener() *schema.Resource {
 			StateContext: schema.ImportStatePassthroughContext,
 		},
 		Schema: getLoadBalancerListenerSchema(RESOURCE),
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
 	}
 }
 
@@ -105,7 +112,7 @@ func getLoadBalancerListenerSchema(t SchemaType) map[string]*schema.Schema {
 }
 
 func createLoadBalancerListener(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 
 	opts := &cloudscale.LoadBalancerListenerRequest{
 		Name:         d.Get("name").(string),
@@ -174,12 +181,12 @@ func gatherLoadBalancerListenerResourceData(loadbalancerlistener *cloudscale.Loa
 }
 
 func readLoadBalancerListener(ctx context.Context, rId GenericResourceIdentifier, meta any) (*cloudscale.LoadBalancerListener, error) {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.LoadBalancerListeners.Get(ctx, rId.Id)
 }
 
 func updateLoadBalancerListener(ctx context.Context, rId GenericResourceIdentifier, meta any, updateRequest *cloudscale.LoadBalancerListenerRequest) error {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.LoadBalancerListeners.Update(ctx, rId.Id, updateRequest)
 }
 
@@ -225,6 +232,6 @@ func gatherLoadBalancerListenerUpdateRequest(d *schema.ResourceData) []*cloudsca
 }
 
 func deleteLoadBalancerListener(ctx context.Context, rId GenericResourceIdentifier, meta any) error {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.LoadBalancerListeners.Delete(ctx, rId.Id)
 }
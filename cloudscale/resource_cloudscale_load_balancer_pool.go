@@ -4,21 +4,29 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/netip"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/cloudscale-ch/cloudscale-go-sdk/v10"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 const poolHumanName = "load balancer pool"
 
-// Pool operations serialize on the parent load balancer
-// via lockKeyFromLoadBalancerUUID.
+// Pool operations serialize on the parent load balancer, named by
+// loadBalancerUUIDFromAttribute, until it is running again. Changes of the
+// inline members are applied in the same locked operation.
 var (
-	resourceCloudscaleLoadBalancerPoolCreate = getCreateOperation(createLoadBalancerPool, lockKeyFromLoadBalancerUUID)
-	resourceCloudscaleLoadBalancerPoolRead   = getReadOperation(poolHumanName, getGenericResourceIdentifierFromSchema, readLoadBalancerPool, gatherLoadBalancerPoolResourceData)
-	resourceCloudscaleLoadBalancerPoolUpdate = getUpdateOperation(poolHumanName, getGenericResourceIdentifierFromSchema, updateLoadBalancerPool, resourceCloudscaleLoadBalancerPoolRead, gatherLoadBalancerPoolUpdateRequest, lockKeyFromLoadBalancerUUID)
-	resourceCloudscaleLoadBalancerPoolDelete = getDeleteOperation(poolHumanName, getGenericResourceIdentifierFromSchema, deleteLoadBalancerPool, lockKeyFromLoadBalancerUUID)
+	resourceCloudscaleLoadBalancerPoolCreate = lockLoadBalancerUntilRunning(loadBalancerUUIDFromAttribute, schema.TimeoutCreate, getCreateOperation(createLoadBalancerPool, nil))
+	resourceCloudscaleLoadBalancerPoolRead   = readLoadBalancerPoolInlineMembers(getReadOperation(poolHumanName, getGenericResourceIdentifierFromSchema, readLoadBalancerPool, gatherLoadBalancerPoolResourceData))
+	resourceCloudscaleLoadBalancerPoolUpdate = lockLoadBalancerUntilRunning(loadBalancerUUIDFromAttribute, schema.TimeoutUpdate, updateLoadBalancerPoolAndMembers)
+	resourceCloudscaleLoadBalancerPoolDelete = lockLoadBalancerUntilRunning(loadBalancerUUIDFromAttribute, schema.TimeoutDelete, getDeleteOperation(poolHumanName, getGenericResourceIdentifierFromSchema, deleteLoadBalancerPool, nil))
+
+	updateLoadBalancerPoolAttributes = getUpdateOperation(poolHumanName, getGenericResourceIdentifierFromSchema, updateLoadBalancerPool, resourceCloudscaleLoadBalancerPoolRead, gatherLoadBalancerPoolUpdateRequest, nil)
 )
 
 func resourceCloudscaleLoadBalancerPool() *schema.Resource {
@@ -31,7 +39,13 @@ func resourceCloudscaleLoadBalancerPool() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
-		Schema: getLoadBalancerPoolSchema(RESOURCE),
+		Schema:        getLoadBalancerPoolSchema(RESOURCE),
+		CustomizeDiff: validateInlinePoolMembers,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
 	}
 }
 
@@ -79,12 +93,44 @@ func getLoadBalancerPoolSchema(t SchemaType) map[string]*schema.Schema {
 			Type:     schema.TypeString,
 			Optional: true,
 		}
+	} else {
+		m["members"] = &schema.Schema{
+			Type:     schema.TypeSet,
+			Optional: true,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"name": {
+						Type:     schema.TypeString,
+						Required: true,
+					},
+					"address": {
+						Type:         schema.TypeString,
+						Required:     true,
+						ValidateFunc: validateCanonicalIPAddress,
+					},
+					"subnet_uuid": {
+						Type:     schema.TypeString,
+						Required: true,
+					},
+					"protocol_port": {
+						Type:         schema.TypeInt,
+						Required:     true,
+						ValidateFunc: validation.IsPortNumber,
+					},
+					"enabled": {
+						Type:     schema.TypeBool,
+						Optional: true,
+						Default:  true,
+					},
+				},
+			},
+		}
 	}
 	return m
 }
 
 func createLoadBalancerPool(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 
 	opts := &cloudscale.LoadBalancerPoolRequest{
 		Name:         d.Get("name").(string),
@@ -105,6 +151,13 @@ func createLoadBalancerPool(ctx context.Context, d *schema.ResourceData, meta in
 	d.SetId(loadBalancerPool.UUID)
 
 	log.Printf("[INFO] LoadBalancerPool ID: %s", d.Id())
+
+	if d.HasChange("members") {
+		err = applyLoadBalancerPoolInlineMembers(ctx, d, meta, d.Timeout(schema.TimeoutCreate))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
 	return resourceCloudscaleLoadBalancerPoolRead(ctx, d, meta)
 }
 
@@ -123,12 +176,12 @@ func gatherLoadBalancerPoolResourceData(loadbalancerpool *cloudscale.LoadBalance
 }
 
 func readLoadBalancerPool(ctx context.Context, rId GenericResourceIdentifier, meta any) (*cloudscale.LoadBalancerPool, error) {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.LoadBalancerPools.Get(ctx, rId.Id)
 }
 
 func updateLoadBalancerPool(ctx context.Context, rId GenericResourceIdentifier, meta any, updateRequest *cloudscale.LoadBalancerPoolRequest) error {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.LoadBalancerPools.Update(ctx, rId.Id, updateRequest)
 }
 
@@ -152,6 +205,301 @@ func gatherLoadBalancerPoolUpdateRequest(d *schema.ResourceData) []*cloudscale.L
 }
 
 func deleteLoadBalancerPool(ctx context.Context, rId GenericResourceIdentifier, meta any) error {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.LoadBalancerPools.Delete(ctx, rId.Id)
 }
+
+// updateLoadBalancerPoolAndMembers applies the changes of the inline members
+// before the ones of the pool itself, whose update refreshes the members.
+func updateLoadBalancerPoolAndMembers(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+	if d.HasChange("members") {
+		err := applyLoadBalancerPoolInlineMembers(ctx, d, meta, d.Timeout(schema.TimeoutUpdate))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	return updateLoadBalancerPoolAttributes(ctx, d, meta)
+}
+
+// inlinePoolMember is an element of the members set of a pool. Members are
+// identified by where they send traffic to: the address, its subnet and the
+// port. Their name and enabled flag can be changed in place.
+type inlinePoolMember struct {
+	Name         string
+	Address      string
+	SubnetUUID   string
+	ProtocolPort int
+	Enabled      bool
+}
+
+func (m inlinePoolMember) key() string {
+	return fmt.Sprintf("%s/%s:%d", m.SubnetUUID, m.Address, m.ProtocolPort)
+}
+
+// normalizeIPAddress returns the canonical form of an IP address, so that
+// e.g. "2001:DB8::1" and "2001:db8:0::1" refer to the same member. Invalid
+// addresses are returned unchanged.
+func normalizeIPAddress(address string) string {
+	addr, err := netip.ParseAddr(address)
+	if err != nil {
+		return address
+	}
+	return addr.String()
+}
+
+// validateCanonicalIPAddress accepts IP addresses in the form the API returns
+// them, e.g. 2001:db8::1 but not 2001:DB8:0::1. Otherwise the member would
+// hash differently in the set than the one read back, and show a diff on
+// every plan.
+func validateCanonicalIPAddress(v any, k string) ([]string, []error) {
+	value := v.(string)
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return nil, []error{fmt.Errorf("expected %s to be an IP address, got %q: %s", k, value, err)}
+	}
+	if addr.String() != value {
+		return nil, []error{fmt.Errorf("expected %s to be an IP address in canonical form, got %q, did you mean %q?", k, value, addr.String())}
+	}
+	return nil, nil
+}
+
+func inlinePoolMembersFromSet(set *schema.Set) map[string]inlinePoolMember {
+	members := make(map[string]inlinePoolMember, set.Len())
+	for _, raw := range set.List() {
+		attrs := raw.(map[string]any)
+		member := inlinePoolMember{
+			Name:         attrs["name"].(string),
+			Address:      normalizeIPAddress(attrs["address"].(string)),
+			SubnetUUID:   attrs["subnet_uuid"].(string),
+			ProtocolPort: attrs["protocol_port"].(int),
+			Enabled:      attrs["enabled"].(bool),
+		}
+		members[member.key()] = member
+	}
+	return members
+}
+
+// validateInlinePoolMembers rejects members that only differ by their name or
+// enabled flag. They would send traffic to the same address, subnet and port,
+// which can't be told apart when applying the members. Members with unknown
+// values are checked once the values are known.
+func validateInlinePoolMembers(_ context.Context, d *schema.ResourceDiff, _ any) error {
+	config := d.GetRawConfig()
+	if config.IsNull() || !config.IsKnown() {
+		return nil
+	}
+	rawMembers := config.GetAttr("members")
+	if rawMembers.IsNull() || !rawMembers.IsKnown() {
+		return nil
+	}
+
+	var members []inlinePoolMember
+	for it := rawMembers.ElementIterator(); it.Next(); {
+		_, rawMember := it.Element()
+		if rawMember.IsNull() || !rawMember.IsKnown() {
+			continue
+		}
+		address, subnetUUID, protocolPort := rawMember.GetAttr("address"), rawMember.GetAttr("subnet_uuid"), rawMember.GetAttr("protocol_port")
+		if !address.IsKnown() || !subnetUUID.IsKnown() || !protocolPort.IsKnown() || address.IsNull() || subnetUUID.IsNull() || protocolPort.IsNull() {
+			continue
+		}
+		port, _ := protocolPort.AsBigFloat().Int64()
+		members = append(members, inlinePoolMember{
+			Address:      normalizeIPAddress(address.AsString()),
+			SubnetUUID:   subnetUUID.AsString(),
+			ProtocolPort: int(port),
+		})
+	}
+	if key, ok := duplicateInlinePoolMember(members); ok {
+		return fmt.Errorf("members: more than one member has the address, subnet_uuid and protocol_port %s", key)
+	}
+	return nil
+}
+
+// duplicateInlinePoolMember returns the key of the first member that has the
+// same key as one before it.
+func duplicateInlinePoolMember(members []inlinePoolMember) (string, bool) {
+	seen := make(map[string]bool, len(members))
+	for _, member := range members {
+		if seen[member.key()] {
+			return member.key(), true
+		}
+		seen[member.key()] = true
+	}
+	return "", false
+}
+
+// diffInlinePoolMembers returns the members to remove, to add and to change
+// to get from oldMembers to newMembers, each sorted by key so that the changes are applied
+// in a stable order.
+func diffInlinePoolMembers(oldMembers, newMembers map[string]inlinePoolMember) (removed, added, changed []inlinePoolMember) {
+	for key, member := range oldMembers {
+		if _, ok := newMembers[key]; !ok {
+			removed = append(removed, member)
+		}
+	}
+	for key, member := range newMembers {
+		if oldMember, ok := oldMembers[key]; !ok {
+			added = append(added, member)
+		} else if oldMember != member {
+			changed = append(changed, member)
+		}
+	}
+	byKey := func(a, b inlinePoolMember) int {
+		return strings.Compare(a.key(), b.key())
+	}
+	slices.SortFunc(removed, byKey)
+	slices.SortFunc(added, byKey)
+	slices.SortFunc(changed, byKey)
+	return removed, added, changed
+}
+
+// applyLoadBalancerPoolInlineMembers brings the members of the pool in line
+// with the members set, as one batch under the load balancer's lock. The load
+// balancer applies one change at a time, so each request is sent once it is
+// running again. Members are removed first, to free their addresses.
+func applyLoadBalancerPoolInlineMembers(ctx context.Context, d *schema.ResourceData, meta any, timeout time.Duration) error {
+	startTime := time.Now()
+	client := meta.(*providerMeta).Client
+	poolID := d.Id()
+	lbUUID := d.Get("load_balancer_uuid").(string)
+
+	o, n := d.GetChange("members")
+	removed, added, changed := diffInlinePoolMembers(inlinePoolMembersFromSet(o.(*schema.Set)), inlinePoolMembersFromSet(n.(*schema.Set)))
+	log.Printf("[INFO] Changing the members of load balancer pool %s: %d to remove, %d to add, %d to change", poolID, len(removed), len(added), len(changed))
+
+	// The members are matched by key, as the set doesn't store their UUIDs.
+	existingMembers, err := client.LoadBalancerPoolMembers.List(ctx, poolID)
+	if err != nil {
+		return fmt.Errorf("error listing the members of load balancer pool %s: %s", poolID, err)
+	}
+	existing := make(map[string]string, len(existingMembers))
+	for _, member := range existingMembers {
+		existing[poolMemberKey(member)] = member.UUID
+	}
+
+	apply := func(member inlinePoolMember, action string, request func() error) error {
+		remainingTime := timeout - time.Since(startTime)
+		if err := waitForLoadBalancerRunning(ctx, lbUUID, &remainingTime, meta); err != nil {
+			return err
+		}
+		log.Printf("[DEBUG] %s load balancer pool member %s", action, member.key())
+		if err := request(); err != nil {
+			return fmt.Errorf("error %s load balancer pool member %s of pool %s: %s", strings.ToLower(action), member.key(), poolID, err)
+		}
+		return nil
+	}
+	update := func(member inlinePoolMember, uuid string) error {
+		return apply(member, "Updating", func() error {
+			return client.LoadBalancerPoolMembers.Update(ctx, poolID, uuid, &cloudscale.LoadBalancerPoolMemberRequest{
+				Name:    member.Name,
+				Enabled: &member.Enabled,
+			})
+		})
+	}
+
+	for _, member := range removed {
+		uuid, ok := existing[member.key()]
+		if !ok {
+			// Already gone.
+			continue
+		}
+		err := apply(member, "Deleting", func() error {
+			return client.LoadBalancerPoolMembers.Delete(ctx, poolID, uuid)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for _, member := range changed {
+		uuid, ok := existing[member.key()]
+		if !ok {
+			// Deleted out of band, add it again.
+			added = append(added, member)
+			continue
+		}
+		if err := update(member, uuid); err != nil {
+			return err
+		}
+	}
+	for _, member := range added {
+		if uuid, ok := existing[member.key()]; ok {
+			// Created out of band or by a previous, partially failed apply.
+			if err := update(member, uuid); err != nil {
+				return err
+			}
+			continue
+		}
+		err := apply(member, "Creating", func() error {
+			_, err := client.LoadBalancerPoolMembers.Create(ctx, poolID, &cloudscale.LoadBalancerPoolMemberRequest{
+				Name:         member.Name,
+				Enabled:      &member.Enabled,
+				ProtocolPort: member.ProtocolPort,
+				Address:      member.Address,
+				Subnet:       member.SubnetUUID,
+			})
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	remainingTime := timeout - time.Since(startTime)
+	return waitForLoadBalancerRunning(ctx, lbUUID, &remainingTime, meta)
+}
+
+func poolMemberKey(member cloudscale.LoadBalancerPoolMember) string {
+	return inlinePoolMember{
+		Address:      normalizeIPAddress(member.Address),
+		SubnetUUID:   member.Subnet.UUID,
+		ProtocolPort: member.ProtocolPort,
+	}.key()
+}
+
+// readLoadBalancerPoolInlineMembers wraps the read of the pool to refresh the
+// members set, but only if the pool manages its members inline. Otherwise
+// the members are managed as cloudscale_load_balancer_pool_member resources,
+// which must not show up as drift of the pool. The members set is exclusive:
+// members that aren't in it show up as drift and are removed by the next
+// apply, so they are reported with a warning.
+func readLoadBalancerPoolInlineMembers(read schema.ReadContextFunc) schema.ReadContextFunc {
+	return func(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+		diags := read(ctx, d, meta)
+		if diags.HasError() || d.Id() == "" || d.Get("members").(*schema.Set).Len() == 0 {
+			return diags
+		}
+		managed := inlinePoolMembersFromSet(d.Get("members").(*schema.Set))
+
+		client := meta.(*providerMeta).Client
+		poolMembers, err := client.LoadBalancerPoolMembers.List(ctx, d.Id())
+		if err != nil {
+			return append(diags, diag.Errorf("Error retrieving the members of load balancer pool %s: %s", d.Id(), err)...)
+		}
+		members := make([]map[string]any, 0, len(poolMembers))
+		var unmanaged []string
+		for _, member := range poolMembers {
+			if _, ok := managed[poolMemberKey(member)]; !ok {
+				unmanaged = append(unmanaged, fmt.Sprintf("%s (%s)", member.Name, poolMemberKey(member)))
+			}
+			members = append(members, map[string]any{
+				"name":          member.Name,
+				"address":       member.Address,
+				"subnet_uuid":   member.Subnet.UUID,
+				"protocol_port": member.ProtocolPort,
+				"enabled":       member.Enabled,
+			})
+		}
+		d.Set("members", members)
+		if len(unmanaged) > 0 {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  fmt.Sprintf("Load balancer pool %s has members that are not in its members set", d.Id()),
+				Detail: fmt.Sprintf("The next apply removes these members: %s. "+
+					"A pool either manages its members inline or as cloudscale_load_balancer_pool_member resources, not both.",
+					strings.Join(unmanaged, ", ")),
+			})
+		}
+		return diags
+	}
+}
@@ -4,29 +4,32 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/cloudscale-ch/cloudscale-go-sdk/v10"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 const poolMemberHumanName = "load balancer pool member"
 
-// Pool member operations serialize on the load balancer that owns the parent pool
-// via lockKeyFromPoolUUID.
+// Pool member operations serialize on the load balancer that owns the parent pool,
+// resolved by loadBalancerUUIDFromStateOrPool, until it is running again.
 var (
-	resourceCloudscaleLoadBalancerPoolMemberCreate = getCreateOperation(createLoadBalancerPoolMember, lockKeyFromPoolUUID)
-	resourceCloudscaleLoadBalancerPoolMemberRead   = getReadOperation(poolMemberHumanName, getLoadBalancerResourceIdentifierFromSchema, readLoadBalancerPoolMember, gatherLoadBalancerPoolMemberResourceData)
-	resourceCloudscaleLoadBalancerPoolMemberUpdate = getUpdateOperation(poolMemberHumanName, getLoadBalancerResourceIdentifierFromSchema, updateLoadBalancerPoolMember, resourceCloudscaleLoadBalancerPoolMemberRead, gatherLoadBalancerPoolMemberUpdateRequest, lockKeyFromPoolUUID)
-	resourceCloudscaleLoadBalancerPoolMemberDelete = getDeleteOperation(poolMemberHumanName, getLoadBalancerResourceIdentifierFromSchema, deleteLoadBalancerPoolMember, lockKeyFromPoolUUID)
+	resourceCloudscaleLoadBalancerPoolMemberCreate = lockLoadBalancerUntilRunning(loadBalancerUUIDFromStateOrPool, schema.TimeoutCreate, getCreateOperation(createLoadBalancerPoolMember, nil))
+	resourceCloudscaleLoadBalancerPoolMemberRead   = readWithLoadBalancerUUID(getReadOperation(poolMemberHumanName, getLoadBalancerResourceIdentifierFromSchema, readLoadBalancerPoolMember, gatherLoadBalancerPoolMemberResourceData))
+	resourceCloudscaleLoadBalancerPoolMemberUpdate = lockLoadBalancerUntilRunning(loadBalancerUUIDFromStateOrPool, schema.TimeoutUpdate, getUpdateOperation(poolMemberHumanName, getLoadBalancerResourceIdentifierFromSchema, updateLoadBalancerPoolMember, resourceCloudscaleLoadBalancerPoolMemberRead, gatherLoadBalancerPoolMemberUpdateRequest, nil))
+	resourceCloudscaleLoadBalancerPoolMemberDelete = lockLoadBalancerUntilRunning(loadBalancerUUIDFromStateOrPool, schema.TimeoutDelete, getDeleteOperation(poolMemberHumanName, getLoadBalancerResourceIdentifierFromSchema, deleteLoadBalancerPoolMember, nil))
 )
 
 func resourceCloudscaleLoadBalancerPoolMembers() *schema.Resource {
 	return &schema.Resource{
-		CreateContext: resourceCloudscaleLoadBalancerPoolMemberCreate,
+		CreateContext: createLoadBalancerPoolMemberAndWait,
 		ReadContext:   resourceCloudscaleLoadBalancerPoolMemberRead,
 		UpdateContext: resourceCloudscaleLoadBalancerPoolMemberUpdate,
-		DeleteContext: resourceCloudscaleLoadBalancerPoolMemberDelete,
+		DeleteContext: drainAndDeleteLoadBalancerPoolMember,
 
 		Importer: &schema.ResourceImporter{
 			StateContext: func(
@@ -47,6 +50,11 @@ func resourceCloudscaleLoadBalancerPoolMembers() *schema.Resource {
 			},
 		},
 		Schema: getLoadBalancerPoolMemberSchema(RESOURCE),
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
 	}
 }
 
@@ -134,12 +142,27 @@ func getLoadBalancerPoolMemberSchema(t SchemaType) map[string]*schema.Schema {
 			Type:     schema.TypeString,
 			Optional: true,
 		}
+	} else {
+		m["load_balancer_uuid"] = &schema.Schema{
+			Type:     schema.TypeString,
+			Computed: true,
+		}
+		m["wait_for_monitor_status"] = &schema.Schema{
+			Type:         schema.TypeString,
+			Optional:     true,
+			ValidateFunc: validation.StringInSlice([]string{"up"}, false),
+		}
+		m["drain_timeout"] = &schema.Schema{
+			Type:         schema.TypeString,
+			Optional:     true,
+			ValidateFunc: validateDuration,
+		}
 	}
 	return m
 }
 
 func createLoadBalancerPoolMember(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 
 	opts := &cloudscale.LoadBalancerPoolMemberRequest{
 		Name:         d.Get("name").(string),
@@ -168,6 +191,74 @@ func createLoadBalancerPoolMember(ctx context.Context, d *schema.ResourceData, m
 	return resourceCloudscaleLoadBalancerPoolMemberRead(ctx, d, meta)
 }
 
+// createLoadBalancerPoolMemberAndWait creates the member under the load
+// balancer's lock, but waits for its monitor status without holding it. The
+// member doesn't change while it is being monitored, so sibling operations on
+// the same load balancer can proceed in the meantime.
+func createLoadBalancerPoolMemberAndWait(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+	startTime := time.Now()
+
+	target, wait := d.GetOk("wait_for_monitor_status")
+	if wait {
+		if err := checkPoolMemberCanBecomeUp(ctx, d, meta); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	diags := resourceCloudscaleLoadBalancerPoolMemberCreate(ctx, d, meta)
+	if diags.HasError() || !wait {
+		return diags
+	}
+	remainingTime := d.Timeout(schema.TimeoutCreate) - time.Since(startTime)
+	_, err := waitForStatus(ctx, []string{"changing", "down"}, target.(string), &remainingTime, newLoadBalancerPoolMemberRefreshFunc(ctx, d, "monitor_status", meta))
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error waiting for load balancer pool member (%s) to be %s: %s", d.Id(), target, err))
+	}
+
+	return resourceCloudscaleLoadBalancerPoolMemberRead(ctx, d, meta)
+}
+
+// checkPoolMemberCanBecomeUp fails early if the monitor status of the member
+// would never become up: a disabled member or one in a pool without a health
+// monitor stays in the pending states until the create timeout.
+func checkPoolMemberCanBecomeUp(ctx context.Context, d *schema.ResourceData, meta any) error {
+	if enabled, ok := d.GetOkExists("enabled"); ok && !enabled.(bool) {
+		return fmt.Errorf("wait_for_monitor_status requires the load balancer pool member to be enabled")
+	}
+
+	client := meta.(*providerMeta).Client
+	poolID := d.Get("pool_uuid").(string)
+	healthMonitors, err := client.LoadBalancerHealthMonitors.List(ctx)
+	if err != nil {
+		return fmt.Errorf("error retrieving the health monitors of load balancer pool (%s): %s", poolID, err)
+	}
+	for _, healthMonitor := range healthMonitors {
+		if healthMonitor.Pool.UUID == poolID {
+			return nil
+		}
+	}
+	return fmt.Errorf("wait_for_monitor_status requires a health monitor, but load balancer pool (%s) has none. Create it before the member, e.g. with depends_on", poolID)
+}
+
+func newLoadBalancerPoolMemberRefreshFunc(ctx context.Context, d *schema.ResourceData, attribute string, meta any) resource.StateRefreshFunc {
+	return func() (any, string, error) {
+		rId := getLoadBalancerResourceIdentifierFromSchema(d)
+
+		poolMember, err := readLoadBalancerPoolMember(ctx, rId, meta)
+		if err != nil {
+			return nil, "", fmt.Errorf("error retrieving load balancer pool member (%s) (refresh) %s", rId.Id, err)
+		}
+
+		data := gatherLoadBalancerPoolMemberResourceData(poolMember)
+		attr, ok := data[attribute]
+		if !ok {
+			return nil, "", nil
+		}
+
+		return poolMember, attr.(string), nil
+	}
+}
+
 func gatherLoadBalancerPoolMemberResourceData(loadbalancerPoolMember *cloudscale.LoadBalancerPoolMember) ResourceDataRaw {
 	m := make(map[string]any)
 	m["id"] = loadbalancerPoolMember.UUID
@@ -189,12 +280,12 @@ func gatherLoadBalancerPoolMemberResourceData(loadbalancerPoolMember *cloudscale
 }
 
 func readLoadBalancerPoolMember(ctx context.Context, rId LoadBalancerPoolMemberResourceIdentifier, meta any) (*cloudscale.LoadBalancerPoolMember, error) {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.LoadBalancerPoolMembers.Get(ctx, rId.PoolID, rId.Id)
 }
 
 func updateLoadBalancerPoolMember(ctx context.Context, rId LoadBalancerPoolMemberResourceIdentifier, meta any, updateRequest *cloudscale.LoadBalancerPoolMemberRequest) error {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.LoadBalancerPoolMembers.Update(ctx, rId.PoolID, rId.Id, updateRequest)
 }
 
@@ -220,7 +311,72 @@ func gatherLoadBalancerPoolMemberUpdateRequest(d *schema.ResourceData) []*clouds
 	return requests
 }
 
+// drainAndDeleteLoadBalancerPoolMember disables the member before deleting it
+// when drain_timeout is set, so that its connections can finish. Like the
+// monitor status wait on create, the drain period doesn't hold the load
+// balancer's lock.
+func drainAndDeleteLoadBalancerPoolMember(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+	if attr, ok := d.GetOk("drain_timeout"); ok && d.Get("enabled").(bool) {
+		timeout, err := time.ParseDuration(attr.(string))
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("invalid drain_timeout %q: %s", attr, err))
+		}
+		diags := drainLoadBalancerPoolMember(ctx, d, meta, timeout)
+		if diags.HasError() {
+			return diags
+		}
+		if d.Id() == "" {
+			// The member is already gone.
+			return nil
+		}
+	}
+	return resourceCloudscaleLoadBalancerPoolMemberDelete(ctx, d, meta)
+}
+
+// drainLoadBalancerPoolMember disables the member, so it doesn't receive new
+// connections, and waits for timeout. The API doesn't report the connections
+// of a member, so the whole period is waited for. The wait ends in time to
+// delete the member within the delete timeout.
+func drainLoadBalancerPoolMember(ctx context.Context, d *schema.ResourceData, meta any, timeout time.Duration) diag.Diagnostics {
+	diags := resourceCloudscaleLoadBalancerPoolMemberDisable(ctx, d, meta)
+	if diags.HasError() || d.Id() == "" {
+		return diags
+	}
+	// Should the deletion fail, the state shows that the member is disabled.
+	d.Set("enabled", false)
+
+	if remaining := capToDeadline(ctx, timeout); remaining < timeout {
+		log.Printf("[WARN] drain_timeout %s exceeds the delete timeout, draining load balancer pool member (%s) only for %s", timeout, d.Id(), max(remaining, 0))
+		timeout = remaining
+	}
+	if timeout <= 0 {
+		return diags
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return diags
+	case <-ctx.Done():
+		return diag.Errorf("error draining load balancer pool member (%s): %s", d.Id(), ctx.Err())
+	}
+}
+
+var resourceCloudscaleLoadBalancerPoolMemberDisable = lockLoadBalancerUntilRunning(loadBalancerUUIDFromStateOrPool, schema.TimeoutDelete, disableLoadBalancerPoolMember)
+
+func disableLoadBalancerPoolMember(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+	rId := getLoadBalancerResourceIdentifierFromSchema(d)
+	enabled := false
+	log.Printf("[INFO] Disabling load balancer pool member (%s) to drain it", rId.Id)
+	err := updateLoadBalancerPoolMember(ctx, rId, meta, &cloudscale.LoadBalancerPoolMemberRequest{Enabled: &enabled})
+	if err != nil {
+		return diag.FromErr(CheckDeleted(d, err, "Error draining load balancer pool member"))
+	}
+	return nil
+}
+
 func deleteLoadBalancerPoolMember(ctx context.Context, rId LoadBalancerPoolMemberResourceIdentifier, meta any) error {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.LoadBalancerPoolMembers.Delete(ctx, rId.PoolID, rId.Id)
 }
@@ -1,13 +1,20 @@
 package cloudscale
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/cloudscale-ch/cloudscale-go-sdk/v10"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"io"
+	"net/http"
 	"regexp"
+	"strings"
 	"testing"
+	"time"
 )
 
 var TestAddress = "10.100.10.100"
@@ -68,6 +75,8 @@ func TestAccCloudscaleLoadBalancerPoolMember_Basic(t *testing.T) {
 						resourceName, "pool_name", &loadBalancerPool.Name),
 					resource.TestCheckResourceAttrPtr(
 						resourceName, "pool_href", &loadBalancerPool.HREF),
+					resource.TestCheckResourceAttrPtr(
+						resourceName, "load_balancer_uuid", &loadBalancer.UUID),
 				),
 			},
 		},
@@ -415,6 +424,179 @@ func TestAccCloudscaleLoadBalancerPoolMember_tags(t *testing.T) {
 	})
 }
 
+func TestAccCloudscaleLoadBalancerPoolMember_WaitForMonitorStatus(t *testing.T) {
+	rInt := acctest.RandInt()
+
+	resourceName := "cloudscale_load_balancer_pool_member.lb-pool-member-acc-test"
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudscaleLoadBalancerDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudscaleLoadBalancerConfig_basic(rInt) +
+					testAccCloudscaleLoadBalancerPoolConfig_basic(rInt) +
+					testAccCloudscaleLoadBalancerListenerConfig_basic(rInt) +
+					testAccCloudscaleLoadBalancerHealthMonitorConfig_basic(rInt, 10) +
+					testAccCloudscaleLoadBalancerPoolMemberConfig_serverWith(rInt, `wait_for_monitor_status = "up"
+  depends_on              = [cloudscale_load_balancer_health_monitor.lb-health_monitor-acc-test]`),
+				Check: resource.ComposeTestCheckFunc(
+					// No waitForMonitorStatus needed: the apply only completes once the member is up.
+					resource.TestCheckResourceAttr(
+						resourceName, "monitor_status", "up"),
+					resource.TestCheckResourceAttr(
+						resourceName, "wait_for_monitor_status", "up"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccCloudscaleLoadBalancerPoolMember_DrainTimeout(t *testing.T) {
+	var loadBalancerPoolMember cloudscale.LoadBalancerPoolMember
+
+	rInt := acctest.RandInt()
+
+	resourceName := "cloudscale_load_balancer_pool_member.lb-pool-member-acc-test"
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudscaleLoadBalancerDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudscaleLoadBalancerConfig_basic(rInt) +
+					testAccCloudscaleLoadBalancerPoolConfig_basic(rInt) +
+					testAccCloudscaleLoadBalancerPoolMemberConfig_drain(rInt),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudscaleLoadBalancerPoolMemberExists(resourceName, &loadBalancerPoolMember),
+					resource.TestCheckResourceAttr(
+						resourceName, "drain_timeout", "5s"),
+				),
+			},
+			{
+				Config: testAccCloudscaleLoadBalancerConfig_basic(rInt) +
+					testAccCloudscaleLoadBalancerPoolConfig_basic(rInt) +
+					testAccCloudscaleLoadBalancerSubnet(rInt),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudscaleLoadBalancerPoolMemberDeleted(&loadBalancerPoolMember),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckCloudscaleLoadBalancerPoolMemberDeleted(member *cloudscale.LoadBalancerPoolMember) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		client := testAccProvider.Meta().(*providerMeta).Client
+		_, err := client.LoadBalancerPoolMembers.Get(context.Background(), member.Pool.UUID, member.UUID)
+		if err == nil {
+			return fmt.Errorf("LoadBalancerPoolMember %s still exists", member.UUID)
+		}
+		return nil
+	}
+}
+
+func TestDrainLoadBalancerPoolMember(t *testing.T) {
+	var body []byte
+	mux := http.NewServeMux()
+	mux.Handle("/v1/load-balancers/pools/pool-1", poolHandler(t, "pool-1", cloudscale.LoadBalancerPool{
+		UUID:         "pool-1",
+		LoadBalancer: cloudscale.LoadBalancerStub{UUID: "lb-1"},
+	}))
+	mux.HandleFunc("/v1/load-balancers/lb-1", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"uuid": "lb-1", "status": "running"}`)
+	})
+	mux.HandleFunc("/v1/load-balancers/pools/pool-1/members/member-1", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch {
+			t.Errorf("unexpected %s request", r.Method)
+		}
+		var err error
+		body, err = io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("reading request body: %s", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	client := testClient(t, mux)
+	d := schema.TestResourceDataRaw(t, getLoadBalancerPoolMemberSchema(RESOURCE), map[string]any{"pool_uuid": "pool-1"})
+	d.SetId("member-1")
+
+	t.Run("disables the member and waits", func(t *testing.T) {
+		if diags := drainLoadBalancerPoolMember(context.Background(), d, client, 0); diags.HasError() {
+			t.Fatalf("unexpected error: %v", diags)
+		}
+		var request map[string]any
+		if err := json.Unmarshal(body, &request); err != nil {
+			t.Fatalf("decoding request body: %s", err)
+		}
+		if enabled, ok := request["enabled"]; !ok || enabled != false {
+			t.Errorf("got %s, want the member to be disabled", body)
+		}
+		if d.Get("enabled").(bool) {
+			t.Error("the state still shows the member as enabled")
+		}
+	})
+
+	// A drain_timeout beyond the delete timeout must not use up the time
+	// needed to delete the member.
+	t.Run("ends the wait before the delete timeout", func(t *testing.T) {
+		reserve := deadlineReserve
+		deadlineReserve = 200 * time.Millisecond
+		t.Cleanup(func() { deadlineReserve = reserve })
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		if diags := drainLoadBalancerPoolMember(ctx, d, client, time.Hour); diags.HasError() {
+			t.Fatalf("unexpected error: %v", diags)
+		}
+		if err := ctx.Err(); err != nil {
+			t.Errorf("waited until the deadline: %s", err)
+		}
+	})
+
+	t.Run("errors when the context is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(100*time.Millisecond, cancel)
+		if diags := drainLoadBalancerPoolMember(ctx, d, client, time.Hour); !diags.HasError() {
+			t.Error("expected an error when the context is cancelled")
+		}
+	})
+}
+
+func TestCheckPoolMemberCanBecomeUp(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/load-balancers/health-monitors", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `[{"uuid": "monitor-1", "pool": {"uuid": "pool-1"}}]`)
+	})
+	client := testClient(t, mux)
+
+	cases := []struct {
+		name    string
+		raw     map[string]any
+		wantErr string
+	}{
+		{"enabled member in a monitored pool", map[string]any{"pool_uuid": "pool-1"}, ""},
+		{"disabled member", map[string]any{"pool_uuid": "pool-1", "enabled": false}, "to be enabled"},
+		{"pool without a health monitor", map[string]any{"pool_uuid": "pool-2"}, "has none"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, getLoadBalancerPoolMemberSchema(RESOURCE), tc.raw)
+			err := checkPoolMemberCanBecomeUp(context.Background(), d, client)
+			switch {
+			case tc.wantErr == "" && err != nil:
+				t.Errorf("unexpected error: %s", err)
+			case tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)):
+				t.Errorf("got %v, want an error containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
 func testAccCloudscaleLoadBalancerSubnet(rInt int) string {
 	return fmt.Sprintf(
 		`
@@ -461,6 +643,21 @@ resource "cloudscale_load_balancer_pool_member" "lb-pool-member-acc-test" {
 `, testAccCloudscaleLoadBalancerSubnet(rInt), rInt, TestAddress)
 }
 
+func testAccCloudscaleLoadBalancerPoolMemberConfig_drain(rInt int) string {
+	return fmt.Sprintf(`
+%s
+
+resource "cloudscale_load_balancer_pool_member" "lb-pool-member-acc-test" {
+  name          = "terraform-%d-lb-pool-member"
+  pool_uuid     = cloudscale_load_balancer_pool.lb-pool-acc-test.id
+  protocol_port = 80
+  address       = "%s"
+  subnet_uuid   = cloudscale_subnet.lb-subnet.id
+  drain_timeout = "5s"
+}
+`, testAccCloudscaleLoadBalancerSubnet(rInt), rInt, TestAddress)
+}
+
 func testAccCloudscaleLoadBalancerPoolMemberConfigWithTags(rInt int) string {
 	return fmt.Sprintf(`
 %s
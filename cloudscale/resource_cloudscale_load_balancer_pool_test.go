@@ -1,9 +1,11 @@
 package cloudscale
 
 import (
+	"context"
 	"fmt"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 	"regexp"
+	"slices"
 	"testing"
 
 	"github.com/cloudscale-ch/cloudscale-go-sdk/v10"
@@ -288,6 +290,200 @@ func TestAccCloudscaleLoadBalancerPool_tags(t *testing.T) {
 	})
 }
 
+func TestAccCloudscaleLoadBalancerPool_InlineMembers(t *testing.T) {
+	var loadBalancerPool cloudscale.LoadBalancerPool
+
+	rInt := acctest.RandInt()
+
+	resourceName := "cloudscale_load_balancer_pool.lb-pool-acc-test"
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudscaleLoadBalancerDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudscaleLoadBalancerConfig_basic(rInt) +
+					testAccCloudscaleLoadBalancerPoolConfig_members(rInt, `
+  members {
+    name          = "web-1"
+    address       = "10.100.10.101"
+    subnet_uuid   = cloudscale_subnet.lb-subnet.id
+    protocol_port = 80
+  }
+  members {
+    name          = "web-2"
+    address       = "10.100.10.102"
+    subnet_uuid   = cloudscale_subnet.lb-subnet.id
+    protocol_port = 80
+  }`),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudscaleLoadBalancerPoolExists(resourceName, &loadBalancerPool),
+					resource.TestCheckResourceAttr(resourceName, "members.#", "2"),
+					resource.TestCheckTypeSetElemNestedAttrs(resourceName, "members.*", map[string]string{
+						"name":          "web-1",
+						"address":       "10.100.10.101",
+						"protocol_port": "80",
+						"enabled":       "true",
+					}),
+					testAccCheckLoadBalancerPoolMemberNames(&loadBalancerPool, "web-1", "web-2"),
+				),
+			},
+			{
+				// Removes web-2, renames and disables web-1 in place and adds web-3.
+				Config: testAccCloudscaleLoadBalancerConfig_basic(rInt) +
+					testAccCloudscaleLoadBalancerPoolConfig_members(rInt, `
+  members {
+    name          = "web-1-renamed"
+    address       = "10.100.10.101"
+    subnet_uuid   = cloudscale_subnet.lb-subnet.id
+    protocol_port = 80
+    enabled       = false
+  }
+  members {
+    name          = "web-3"
+    address       = "10.100.10.103"
+    subnet_uuid   = cloudscale_subnet.lb-subnet.id
+    protocol_port = 80
+  }`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "members.#", "2"),
+					resource.TestCheckTypeSetElemNestedAttrs(resourceName, "members.*", map[string]string{
+						"name":    "web-1-renamed",
+						"address": "10.100.10.101",
+						"enabled": "false",
+					}),
+					testAccCheckLoadBalancerPoolMemberNames(&loadBalancerPool, "web-1-renamed", "web-3"),
+				),
+			},
+			{
+				// Both members send traffic to 10.100.10.103:80.
+				Config: testAccCloudscaleLoadBalancerConfig_basic(rInt) +
+					testAccCloudscaleLoadBalancerPoolConfig_members(rInt, `
+  members {
+    name          = "web-3"
+    address       = "10.100.10.103"
+    subnet_uuid   = cloudscale_subnet.lb-subnet.id
+    protocol_port = 80
+  }
+  members {
+    name          = "web-3-again"
+    address       = "10.100.10.103"
+    subnet_uuid   = cloudscale_subnet.lb-subnet.id
+    protocol_port = 80
+  }`),
+				ExpectError: regexp.MustCompile(`more than one member has the address, subnet_uuid and protocol_port`),
+			},
+			{
+				Config: testAccCloudscaleLoadBalancerConfig_basic(rInt) +
+					testAccCloudscaleLoadBalancerPoolConfig_members(rInt, ""),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "members.#", "0"),
+					testAccCheckLoadBalancerPoolMemberNames(&loadBalancerPool),
+				),
+			},
+		},
+	})
+}
+
+// testAccCheckLoadBalancerPoolMemberNames checks the members of the pool via
+// the API, as the pool only refreshes its members while it has inline ones.
+func testAccCheckLoadBalancerPoolMemberNames(pool *cloudscale.LoadBalancerPool, names ...string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		client := testAccProvider.Meta().(*providerMeta).Client
+		members, err := client.LoadBalancerPoolMembers.List(context.Background(), pool.UUID)
+		if err != nil {
+			return err
+		}
+		var got []string
+		for _, member := range members {
+			got = append(got, member.Name)
+		}
+		slices.Sort(got)
+		if !slices.Equal(got, names) {
+			return fmt.Errorf("Expected the members %v of pool %s, got %v", names, pool.UUID, got)
+		}
+		return nil
+	}
+}
+
+func testAccCloudscaleLoadBalancerPoolConfig_members(rInt int, members string) string {
+	return fmt.Sprintf(`
+%s
+
+resource "cloudscale_load_balancer_pool" "lb-pool-acc-test" {
+  name = "terraform-%d-lb-pool"
+  algorithm = "round_robin"
+  protocol = "tcp"
+  load_balancer_uuid = cloudscale_load_balancer.lb-acc-test.id
+%s
+}
+`, testAccCloudscaleLoadBalancerSubnet(rInt), rInt, members)
+}
+
+func TestDiffInlinePoolMembers(t *testing.T) {
+	kept := inlinePoolMember{Name: "kept", Address: "10.0.0.1", SubnetUUID: "subnet-1", ProtocolPort: 80, Enabled: true}
+	removed := inlinePoolMember{Name: "removed", Address: "10.0.0.2", SubnetUUID: "subnet-1", ProtocolPort: 80, Enabled: true}
+	renamed := inlinePoolMember{Name: "old", Address: "10.0.0.3", SubnetUUID: "subnet-1", ProtocolPort: 80, Enabled: true}
+	renamedNew := renamed
+	renamedNew.Name = "new"
+	// Same address as renamed, but another port: a different member.
+	added := inlinePoolMember{Name: "added", Address: "10.0.0.3", SubnetUUID: "subnet-1", ProtocolPort: 8080}
+
+	byKey := func(members ...inlinePoolMember) map[string]inlinePoolMember {
+		m := make(map[string]inlinePoolMember)
+		for _, member := range members {
+			m[member.key()] = member
+		}
+		return m
+	}
+
+	gotRemoved, gotAdded, gotChanged := diffInlinePoolMembers(byKey(kept, removed, renamed), byKey(kept, renamedNew, added))
+	if want := []inlinePoolMember{removed}; !slices.Equal(gotRemoved, want) {
+		t.Errorf("removed = %v, want %v", gotRemoved, want)
+	}
+	if want := []inlinePoolMember{added}; !slices.Equal(gotAdded, want) {
+		t.Errorf("added = %v, want %v", gotAdded, want)
+	}
+	if want := []inlinePoolMember{renamedNew}; !slices.Equal(gotChanged, want) {
+		t.Errorf("changed = %v, want %v", gotChanged, want)
+	}
+}
+
+func TestDuplicateInlinePoolMember(t *testing.T) {
+	members := []inlinePoolMember{
+		{Name: "web-1", Address: normalizeIPAddress("2001:db8::1"), SubnetUUID: "subnet-1", ProtocolPort: 80},
+		{Name: "web-1-tls", Address: normalizeIPAddress("2001:db8::1"), SubnetUUID: "subnet-1", ProtocolPort: 443},
+		{Name: "web-2", Address: normalizeIPAddress("2001:db8::1"), SubnetUUID: "subnet-2", ProtocolPort: 80},
+	}
+	if key, ok := duplicateInlinePoolMember(members); ok {
+		t.Fatalf("unexpected duplicate %s", key)
+	}
+
+	// The same address as web-1, spelled differently.
+	members = append(members, inlinePoolMember{Name: "web-1-again", Address: normalizeIPAddress("2001:DB8:0::1"), SubnetUUID: "subnet-1", ProtocolPort: 80})
+	key, ok := duplicateInlinePoolMember(members)
+	if !ok {
+		t.Fatal("expected a duplicate")
+	}
+	if want := "subnet-1/2001:db8::1:80"; key != want {
+		t.Errorf("duplicate = %q, want %q", key, want)
+	}
+}
+
+func TestValidateCanonicalIPAddress(t *testing.T) {
+	for _, address := range []string{"10.11.12.13", "2001:db8::1", "::ffff:10.11.12.13"} {
+		if _, errs := validateCanonicalIPAddress(address, "address"); len(errs) != 0 {
+			t.Errorf("%s: unexpected errors: %v", address, errs)
+		}
+	}
+	for _, address := range []string{"2001:DB8::1", "2001:db8:0::1", "010.11.12.13", "10.11.12.0/24", "not-an-address"} {
+		if _, errs := validateCanonicalIPAddress(address, "address"); len(errs) == 0 {
+			t.Errorf("%s: expected an error", address)
+		}
+	}
+}
+
 func testAccCloudscaleLoadBalancerPoolConfig_basic(rInt int) string {
 	return fmt.Sprintf(`
 resource "cloudscale_load_balancer_pool" "lb-pool-acc-test" {
@@ -30,7 +30,7 @@ func testSweepLoadBalancers(region string) error {
 		return err
 	}
 
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 
 	loadBalancers, err := client.LoadBalancers.List(context.Background())
 	if err != nil {
@@ -143,6 +143,28 @@ func TestAccCloudscaleLoadBalancer_UpdateName(t *testing.T) {
 	})
 }
 
+func TestAccCloudscaleLoadBalancer_InvalidFlavor(t *testing.T) {
+	rInt := acctest.RandInt()
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudscaleLoadBalancerDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+resource "cloudscale_load_balancer" "lb-acc-test" {
+  name        = "terraform-%d-lb"
+  flavor_slug = "lb-standrad"
+  zone_slug   = "rma1"
+}
+`, rInt),
+				ExpectError: regexp.MustCompile(`did you mean "lb-standard"`),
+			},
+		},
+	})
+}
+
 func TestAccCloudscaleLoadBalancer_PrivateNetwork(t *testing.T) {
 	var loadBalancer cloudscale.LoadBalancer
 	var subnet cloudscale.Subnet
@@ -368,7 +390,7 @@ func TestAccCloudscaleLoadBalancerPoolMember_ScenarioSubnet(t *testing.T) {
 }
 
 func testAccCheckCloudscaleLoadBalancerDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*cloudscale.Client)
+	client := testAccProvider.Meta().(*providerMeta).Client
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "cloudscale_load_balancer" {
@@ -537,7 +559,7 @@ resource "cloudscale_load_balancer_pool_member" "basic" {
   pool_uuid     = "${cloudscale_load_balancer_pool.basic.id}"
   protocol_port = 22
   subnet_uuid   = "${cloudscale_subnet.privnet-subnet.id}"    
-  address       = "${cloudscale_server.fixed.interfaces[0].addresses[0].address}"
+  address       = one([for i in cloudscale_server.fixed.interfaces : i.addresses[0].address if i.type == "private"])
 }
 `, rInt1, rInt2, rInt3, rInt4, rInt5, rInt6)
 }
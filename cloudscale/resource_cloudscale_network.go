@@ -12,6 +12,11 @@ import (
 
 const networkHumanName = "network"
 
+const (
+	minNetworkMTU = 1280
+	maxNetworkMTU = 9000
+)
+
 var (
 	resourceCloudscaleNetworkCreate = getCreateOperation(createNetwork, nil)
 	resourceCloudscaleNetworkRead   = getReadOperation(networkHumanName, getGenericResourceIdentifierFromSchema, readNetwork, gatherNetworkResourceData)
@@ -29,8 +34,28 @@ func resourceCloudscaleNetwork() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
-		Schema: getNetworkSchema(RESOURCE),
+		Schema:        getNetworkSchema(RESOURCE),
+		CustomizeDiff: validateNetworkMTU,
+	}
+}
+
+// validateNetworkMTU rejects an MTU the API doesn't accept before apply.
+func validateNetworkMTU(_ context.Context, d *schema.ResourceDiff, _ any) error {
+	if !d.HasChange("mtu") || !d.NewValueKnown("mtu") {
+		return nil
+	}
+	if _, ok := d.GetOk("mtu"); !ok {
+		// The API default is used.
+		return nil
 	}
+	return checkNetworkMTU(d.Get("mtu").(int))
+}
+
+func checkNetworkMTU(mtu int) error {
+	if mtu < minNetworkMTU || mtu > maxNetworkMTU {
+		return fmt.Errorf("mtu must be between %d and %d, got %d", minNetworkMTU, maxNetworkMTU, mtu)
+	}
+	return nil
 }
 
 func getNetworkSchema(t SchemaType) map[string]*schema.Schema {
@@ -87,14 +112,19 @@ func getNetworkSchema(t SchemaType) map[string]*schema.Schema {
 		m["auto_create_ipv4_subnet"] = &schema.Schema{
 			Type:     schema.TypeBool,
 			Optional: true,
-			ForceNew: true,
+			DiffSuppressFunc: func(_, _, _ string, d *schema.ResourceData) bool {
+				// The setting only affects the creation of the network. The
+				// API doesn't return it, so changing it later, or importing
+				// a network, must neither replace nor update the network.
+				return d.Id() != ""
+			},
 		}
 	}
 	return m
 }
 
 func createNetwork(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 
 	opts := &cloudscale.NetworkCreateRequest{
 		Name: d.Get("name").(string),
@@ -147,12 +177,12 @@ func gatherNetworkResourceData(network *cloudscale.Network) ResourceDataRaw {
 }
 
 func readNetwork(ctx context.Context, rId GenericResourceIdentifier, meta any) (*cloudscale.Network, error) {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.Networks.Get(ctx, rId.Id)
 }
 
 func updateNetwork(ctx context.Context, rId GenericResourceIdentifier, meta any, updateRequest *cloudscale.NetworkUpdateRequest) error {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.Networks.Update(ctx, rId.Id, updateRequest)
 }
 
@@ -178,6 +208,6 @@ func gatherNetworkUpdateRequest(d *schema.ResourceData) []*cloudscale.NetworkUpd
 }
 
 func deleteNetwork(ctx context.Context, rId GenericResourceIdentifier, meta any) error {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.Networks.Delete(ctx, rId.Id)
 }
@@ -33,7 +33,7 @@ func testSweepNetworks(region string) error {
 		return err
 	}
 
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 
 	networks, err := client.Networks.List(context.Background())
 	if err != nil {
@@ -191,11 +191,9 @@ func TestAccCloudscaleNetwork_Attach(t *testing.T) {
 				Check: resource.ComposeTestCheckFunc(
 					testAccCheckCloudscaleNetworkExists("cloudscale_network.basic.0", &network),
 					testAccCheckCloudscaleServerExists("cloudscale_server.basic", &server),
-					resource.TestCheckResourceAttr(
-						"cloudscale_server.basic",
-						"interfaces.0.network_name",
-						fmt.Sprintf("terraform-%d-0", rInt1),
-					),
+					resource.TestCheckTypeSetElemNestedAttrs("cloudscale_server.basic", "interfaces.*", map[string]string{
+						"network_name": fmt.Sprintf("terraform-%d-0", rInt1),
+					}),
 				),
 			},
 		},
@@ -229,7 +227,9 @@ func TestAccCloudscaleNetwork_Reattach(t *testing.T) {
 					testAccCheckCloudscaleNetworkExists("cloudscale_network.basic.1", &network1),
 					testAccCheckCloudscaleServerExists("cloudscale_server.basic", &server),
 					resource.TestCheckResourceAttr("cloudscale_server.basic", "interfaces.#", "1"),
-					resource.TestCheckResourceAttr("cloudscale_server.basic", "interfaces.0.network_name", fmt.Sprintf("terraform-%d-0", rInt1)),
+					resource.TestCheckTypeSetElemNestedAttrs("cloudscale_server.basic", "interfaces.*", map[string]string{
+						"network_name": fmt.Sprintf("terraform-%d-0", rInt1),
+					}),
 				),
 			},
 			{
@@ -239,7 +239,9 @@ func TestAccCloudscaleNetwork_Reattach(t *testing.T) {
 					testAccCheckCloudscaleNetworkExists("cloudscale_network.basic.1", &network1),
 					testAccCheckCloudscaleServerExists("cloudscale_server.basic", &server),
 					resource.TestCheckResourceAttr("cloudscale_server.basic", "interfaces.#", "1"),
-					resource.TestCheckResourceAttr("cloudscale_server.basic", "interfaces.0.network_name", fmt.Sprintf("terraform-%d-1", rInt1)),
+					resource.TestCheckTypeSetElemNestedAttrs("cloudscale_server.basic", "interfaces.*", map[string]string{
+						"network_name": fmt.Sprintf("terraform-%d-1", rInt1),
+					}),
 				),
 			},
 			{
@@ -249,8 +251,17 @@ func TestAccCloudscaleNetwork_Reattach(t *testing.T) {
 					testAccCheckCloudscaleNetworkExists("cloudscale_network.basic.1", &network1),
 					testAccCheckCloudscaleServerExists("cloudscale_server.basic", &server),
 					resource.TestCheckResourceAttr("cloudscale_server.basic", "interfaces.#", "2"),
-					resource.TestCheckResourceAttr("cloudscale_server.basic", "interfaces.0.network_name", fmt.Sprintf("terraform-%d-1", rInt1)),
-					resource.TestCheckResourceAttr("cloudscale_server.basic", "interfaces.1.network_name", fmt.Sprintf("terraform-%d-0", rInt1)),
+					resource.TestCheckTypeSetElemNestedAttrs("cloudscale_server.basic", "interfaces.*", map[string]string{
+						"network_name": fmt.Sprintf("terraform-%d-0", rInt1),
+					}),
+					resource.TestCheckTypeSetElemNestedAttrs("cloudscale_server.basic", "interfaces.*", map[string]string{
+						"network_name": fmt.Sprintf("terraform-%d-1", rInt1),
+					}),
+					// The interface that existed before keeps its place.
+					testAccCheckCloudscaleServerInterfaceOrder(&server,
+						fmt.Sprintf("terraform-%d-1", rInt1),
+						fmt.Sprintf("terraform-%d-0", rInt1),
+					),
 				),
 			},
 			{
@@ -260,7 +271,9 @@ func TestAccCloudscaleNetwork_Reattach(t *testing.T) {
 					testAccCheckCloudscaleNetworkExists("cloudscale_network.basic.1", &network1),
 					testAccCheckCloudscaleServerExists("cloudscale_server.basic", &server),
 					resource.TestCheckResourceAttr("cloudscale_server.basic", "interfaces.#", "1"),
-					resource.TestCheckResourceAttr("cloudscale_server.basic", "interfaces.0.network_name", fmt.Sprintf("terraform-%d-0", rInt1)),
+					resource.TestCheckTypeSetElemNestedAttrs("cloudscale_server.basic", "interfaces.*", map[string]string{
+						"network_name": fmt.Sprintf("terraform-%d-0", rInt1),
+					}),
 				),
 			},
 		},
@@ -287,12 +300,16 @@ func TestAccCloudscaleNetwork_ServerWithPublicAndPrivate(t *testing.T) {
 					testAccCheckCloudscaleNetworkExists("cloudscale_network.basic", &network),
 					testAccCheckCloudscaleServerExists("cloudscale_server.basic", &server),
 					resource.TestCheckResourceAttr("cloudscale_server.basic", "interfaces.#", "2"),
-					resource.TestCheckResourceAttr("cloudscale_server.basic", "interfaces.0.type", "public"),
-					resource.TestCheckResourceAttr("cloudscale_server.basic", "interfaces.0.addresses.#", "2"),
-					resource.TestCheckResourceAttr("cloudscale_server.basic", "interfaces.1.type", "private"),
-					resource.TestCheckResourceAttr("cloudscale_server.basic", "interfaces.1.network_name", fmt.Sprintf("terraform-%d", rInt1)),
-					resource.TestCheckResourceAttr("cloudscale_server.basic", "interfaces.1.addresses.#", "1"),
-					resource.TestCheckResourceAttr("cloudscale_server.basic", "interfaces.1.no_address", "false"),
+					resource.TestCheckTypeSetElemNestedAttrs("cloudscale_server.basic", "interfaces.*", map[string]string{
+						"type":        "public",
+						"addresses.#": "2",
+					}),
+					resource.TestCheckTypeSetElemNestedAttrs("cloudscale_server.basic", "interfaces.*", map[string]string{
+						"type":         "private",
+						"network_name": fmt.Sprintf("terraform-%d", rInt1),
+						"addresses.#":  "1",
+						"no_address":   "false",
+					}),
 				),
 			},
 		},
@@ -319,12 +336,16 @@ func TestAccCloudscaleNetwork_ServerWithPublicAndPrivateWithoutAddress(t *testin
 					testAccCheckCloudscaleNetworkExists("cloudscale_network.basic", &network),
 					testAccCheckCloudscaleServerExists("cloudscale_server.basic", &server),
 					resource.TestCheckResourceAttr("cloudscale_server.basic", "interfaces.#", "2"),
-					resource.TestCheckResourceAttr("cloudscale_server.basic", "interfaces.0.type", "public"),
-					resource.TestCheckResourceAttr("cloudscale_server.basic", "interfaces.0.addresses.#", "2"),
-					resource.TestCheckResourceAttr("cloudscale_server.basic", "interfaces.1.type", "private"),
-					resource.TestCheckResourceAttr("cloudscale_server.basic", "interfaces.1.network_name", fmt.Sprintf("terraform-%d", rInt1)),
-					resource.TestCheckResourceAttr("cloudscale_server.basic", "interfaces.1.addresses.#", "0"),
-					resource.TestCheckResourceAttr("cloudscale_server.basic", "interfaces.1.no_address", "true"),
+					resource.TestCheckTypeSetElemNestedAttrs("cloudscale_server.basic", "interfaces.*", map[string]string{
+						"type":        "public",
+						"addresses.#": "2",
+					}),
+					resource.TestCheckTypeSetElemNestedAttrs("cloudscale_server.basic", "interfaces.*", map[string]string{
+						"type":         "private",
+						"network_name": fmt.Sprintf("terraform-%d", rInt1),
+						"addresses.#":  "0",
+						"no_address":   "true",
+					}),
 				),
 			},
 		},
@@ -388,6 +409,55 @@ func TestAccCloudscaleNetwork_import_basic(t *testing.T) {
 	})
 }
 
+func TestAccCloudscaleNetwork_NonDestructiveChanges(t *testing.T) {
+	var beforeChange, afterChange cloudscale.Network
+
+	rInt := acctest.RandInt()
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudscaleNetworkDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: networkconfigMinimal(rInt, true),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudscaleNetworkExists("cloudscale_network.basic", &beforeChange),
+					resource.TestCheckResourceAttr(
+						"cloudscale_network.basic", "subnets.#", "1"),
+				),
+			},
+			{
+				// Only affects the creation, so the network is kept as is.
+				Config: networkconfigMinimal(rInt, false),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudscaleNetworkExists("cloudscale_network.basic", &afterChange),
+					testAccNetworkIsSame(t, &beforeChange, &afterChange),
+					resource.TestCheckResourceAttr(
+						"cloudscale_network.basic", "subnets.#", "1"),
+				),
+			},
+			{
+				Config:      networkconfigMTU(rInt, 100),
+				ExpectError: regexp.MustCompile(`mtu must be between 1280 and 9000, got 100`),
+			},
+		},
+	})
+}
+
+func TestCheckNetworkMTU(t *testing.T) {
+	for _, mtu := range []int{1280, 1500, 9000} {
+		if err := checkNetworkMTU(mtu); err != nil {
+			t.Errorf("%d: unexpected error: %s", mtu, err)
+		}
+	}
+	for _, mtu := range []int{0, 1279, 9001} {
+		if err := checkNetworkMTU(mtu); err == nil {
+			t.Errorf("%d: expected an error", mtu)
+		}
+	}
+}
+
 func TestAccCloudscaleNetwork_tags(t *testing.T) {
 	rInt := acctest.RandInt()
 
@@ -454,7 +524,7 @@ func testAccCheckCloudscaleNetworkSubnetCount(n string, network *cloudscale.Netw
 }
 
 func testAccCheckCloudscaleNetworkDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*cloudscale.Client)
+	client := testAccProvider.Meta().(*providerMeta).Client
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "cloudscale_network" {
@@ -488,6 +558,14 @@ resource "cloudscale_network" "basic" {
 }`, rInt, autoCreateSubnet)
 }
 
+func networkconfigMTU(rInt int, mtu int) string {
+	return fmt.Sprintf(`
+resource "cloudscale_network" "basic" {
+  name = "terraform-%d"
+  mtu  = %d
+}`, rInt, mtu)
+}
+
 func networkConfig_baseline(count int, rInt int) string {
 	return fmt.Sprintf(`
 resource "cloudscale_network" "basic" {
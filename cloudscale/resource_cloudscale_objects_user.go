@@ -78,7 +78,7 @@ func getObjectsUserSchema(t SchemaType) map[string]*schema.Schema {
 }
 
 func createObjectsUser(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 
 	opts := &cloudscale.ObjectsUserRequest{
 		DisplayName: d.Get("display_name").(string),
@@ -118,12 +118,12 @@ func gatherObjectsUserResourceData(objectsUser *cloudscale.ObjectsUser) Resource
 }
 
 func readObjectsUser(ctx context.Context, rId GenericResourceIdentifier, meta any) (*cloudscale.ObjectsUser, error) {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.ObjectsUsers.Get(ctx, rId.Id)
 }
 
 func updateObjectsUser(ctx context.Context, rId GenericResourceIdentifier, meta any, updateRequest *cloudscale.ObjectsUserRequest) error {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.ObjectsUsers.Update(ctx, rId.Id, updateRequest)
 }
 
@@ -146,6 +146,6 @@ func gatherObjectsUserUpdateRequest(d *schema.ResourceData) []*cloudscale.Object
 }
 
 func deleteObjectsUser(ctx context.Context, rId GenericResourceIdentifier, meta any) error {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.ObjectsUsers.Delete(ctx, rId.Id)
 }
@@ -30,7 +30,7 @@ func testSweepObjectsUsers(region string) error {
 		return err
 	}
 
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 
 	ObjectsUsers, err := client.ObjectsUsers.List(context.Background())
 	if err != nil {
@@ -218,7 +218,7 @@ func testAccCheckObjectsUserIsSame(t *testing.T,
 }
 
 func testAccCheckCloudscaleObjectsUserDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*cloudscale.Client)
+	client := testAccProvider.Meta().(*providerMeta).Client
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "cloudscale_objects_user" {
@@ -4,9 +4,12 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/http"
+	"time"
 
 	"github.com/cloudscale-ch/cloudscale-go-sdk/v10"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
@@ -15,19 +18,31 @@ const routerHumanName = "router"
 var (
 	resourceCloudscaleRouterCreate = getCreateOperation(createRouter, nil)
 	resourceCloudscaleRouterRead   = getReadOperation(routerHumanName, getGenericResourceIdentifierFromSchema, readRouter, gatherRouterResourceData)
+	resourceCloudscaleRouterUpdate = getUpdateOperation(routerHumanName, getGenericResourceIdentifierFromSchema, updateRouter, resourceCloudscaleRouterRead, gatherRouterUpdateRequest, lockKeyFromRouterID)
 	resourceCloudscaleRouterDelete = getDeleteOperation(routerHumanName, getGenericResourceIdentifierFromSchema, deleteRouter, nil)
 )
 
+// lockKeyFromRouterID serializes updates of a router with the operations on
+// its interfaces, which lock the same key via interfaceLockKey.
+func lockKeyFromRouterID(_ context.Context, d *schema.ResourceData, _ any) (string, error) {
+	return routerLockKey(d.Id()), nil
+}
+
 func resourceCloudscaleRouter() *schema.Resource {
 	return &schema.Resource{
 		CreateContext: resourceCloudscaleRouterCreate,
 		ReadContext:   resourceCloudscaleRouterRead,
+		UpdateContext: resourceCloudscaleRouterUpdate,
 		DeleteContext: resourceCloudscaleRouterDelete,
 
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
 		Schema: getRouterSchema(RESOURCE),
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
 	}
 }
 
@@ -39,13 +54,11 @@ func addressSchema(writable bool) map[string]*schema.Schema {
 		Type:     schema.TypeString,
 		Required: writable,
 		Computed: !writable,
-		ForceNew: true,
 	}
 	address := &schema.Schema{
 		Type:     schema.TypeString,
 		Required: writable,
 		Computed: !writable,
-		ForceNew: true,
 	}
 	return map[string]*schema.Schema{
 		"address":     address,
@@ -73,17 +86,12 @@ func gatherAddresses(in []cloudscale.IPAddress) []map[string]any {
 }
 
 func getRouterSchema(t SchemaType) map[string]*schema.Schema {
-	// FIXME: update not implemented yet, tags needs ForceNew until this is implemented
-	tagsSchema := TagsSchema
-	tagsSchema.ForceNew = true
-
 	m := map[string]*schema.Schema{
 		"name": {
 			Type:     schema.TypeString,
 			Required: t.isResource(),
 			Optional: t.isDataSource(),
 			Computed: t.isDataSource(),
-			ForceNew: true, // update not implemented yet
 		},
 		"zone_slug": {
 			Type:     schema.TypeString,
@@ -96,7 +104,7 @@ func getRouterSchema(t SchemaType) map[string]*schema.Schema {
 			Type:     schema.TypeString,
 			Computed: true,
 		},
-		"tags": &tagsSchema,
+		"tags": &TagsSchema,
 		"status": {
 			Type:     schema.TypeString,
 			Computed: true,
@@ -105,7 +113,6 @@ func getRouterSchema(t SchemaType) map[string]*schema.Schema {
 			Type:     schema.TypeBool,
 			Optional: t.isResource(),
 			Computed: t.isDataSource(),
-			ForceNew: true, // update not implemented yet
 		},
 		"internet_gateway_addresses": {
 			Type: schema.TypeList,
@@ -164,7 +171,10 @@ func getRouterSchema(t SchemaType) map[string]*schema.Schema {
 }
 
 func createRouter(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
-	client := meta.(*cloudscale.Client)
+	timeout := d.Timeout(schema.TimeoutCreate)
+	startTime := time.Now()
+
+	client := meta.(*providerMeta).Client
 
 	opts := &cloudscale.RouterCreateRequest{
 		Name: d.Get("name").(string),
@@ -188,9 +198,37 @@ func createRouter(ctx context.Context, d *schema.ResourceData, meta any) diag.Di
 	d.SetId(router.UUID)
 
 	log.Printf("[INFO] Router ID %s", d.Id())
+
+	// Interfaces can only be attached once the router is running.
+	remainingTime := timeout - time.Since(startTime)
+	_, err = waitForStatus(ctx, []string{"changing"}, "running", &remainingTime, newRouterRefreshFunc(ctx, d, "status", meta))
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error waiting for router (%s) to become ready: %s", d.Id(), err))
+	}
+
 	return resourceCloudscaleRouterRead(ctx, d, meta)
 }
 
+func newRouterRefreshFunc(ctx context.Context, d *schema.ResourceData, attribute string, meta any) resource.StateRefreshFunc {
+	client := meta.(*providerMeta).Client
+	return func() (any, string, error) {
+		id := d.Id()
+
+		router, err := client.Routers.Get(ctx, id)
+		if err != nil {
+			return nil, "", fmt.Errorf("error retrieving router (%s) (refresh) %s", id, err)
+		}
+
+		data := gatherRouterResourceData(router)
+		attr, ok := data[attribute]
+		if !ok {
+			return nil, "", nil
+		}
+
+		return router, attr.(string), nil
+	}
+}
+
 func gatherRouterResourceData(router *cloudscale.Router) ResourceDataRaw {
 	m := make(map[string]any)
 	m["id"] = router.UUID
@@ -217,11 +255,63 @@ func gatherRouterResourceData(router *cloudscale.Router) ResourceDataRaw {
 }
 
 func readRouter(ctx context.Context, rId GenericResourceIdentifier, meta any) (*cloudscale.Router, error) {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.Routers.Get(ctx, rId.Id)
 }
 
+func updateRouter(ctx context.Context, rId GenericResourceIdentifier, meta any, updateRequest *cloudscale.RouterUpdateRequest) error {
+	client := meta.(*providerMeta).Client
+	return client.Routers.Update(ctx, rId.Id, updateRequest)
+}
+
+func gatherRouterUpdateRequest(d *schema.ResourceData) []*cloudscale.RouterUpdateRequest {
+	requests := make([]*cloudscale.RouterUpdateRequest, 0)
+
+	for _, attribute := range []string{"name", "internet_gateway", "tags"} {
+		if d.HasChange(attribute) {
+			log.Printf("[INFO] Attribute %s changed", attribute)
+			opts := &cloudscale.RouterUpdateRequest{}
+			requests = append(requests, opts)
+
+			if attribute == "name" {
+				opts.Name = d.Get(attribute).(string)
+			} else if attribute == "internet_gateway" {
+				internetGateway := d.Get(attribute).(bool)
+				opts.InternetGateway = &internetGateway
+			} else if attribute == "tags" {
+				opts.Tags = TagsFromState(d)
+			}
+		}
+	}
+	return requests
+}
+
 func deleteRouter(ctx context.Context, rId GenericResourceIdentifier, meta any) error {
-	client := meta.(*cloudscale.Client)
-	return client.Routers.Delete(ctx, rId.Id)
+	client := meta.(*providerMeta).Client
+
+	if err := client.Routers.Delete(ctx, rId.Id); err != nil {
+		return err
+	}
+	// The router keeps its ports on the networks until it is fully gone. Wait
+	// for that, so that deleting those networks doesn't fail.
+	return waitForRouterDeleted(ctx, rId.Id, meta)
+}
+
+func waitForRouterDeleted(ctx context.Context, id string, meta any) error {
+	client := meta.(*providerMeta).Client
+	err := waitForDeleted(ctx, func() (exists bool, err error) {
+		router, err := client.Routers.Get(ctx, id)
+		if err != nil {
+			if errorResponse, ok := err.(*cloudscale.ErrorResponse); ok && errorResponse.StatusCode == http.StatusNotFound {
+				return false, nil
+			}
+			return false, fmt.Errorf("error retrieving router (%s) (delete refresh) %s", id, err)
+		}
+		log.Printf("[INFO] Status is %s", router.Status)
+		return true, nil
+	})
+	if err != nil {
+		return fmt.Errorf("error waiting for router (%s) to be deleted: %s", id, err)
+	}
+	return nil
 }
@@ -0,0 +1,267 @@
+package cloudscale
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/cloudscale-ch/cloudscale-go-sdk/v10"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+const routerRouteHumanName = "router route"
+
+// routerRouteLockKey serializes route operations with all other operations on
+// the router. The routes are stored as one list on the router, so adding or
+// removing a route is a read-modify-write of that list.
+var routerRouteLockKey = uuidLockKey("router_uuid", routerLockKey)
+
+var (
+	resourceCloudscaleRouterRouteCreate = getCreateOperation(createRouterRoute, routerRouteLockKey)
+	resourceCloudscaleRouterRouteRead   = getReadOperation(routerRouteHumanName, getRouterRouteResourceIdentifierFromSchema, readRouterRoute, gatherRouterRouteResourceData)
+	resourceCloudscaleRouterRouteDelete = getDeleteOperation(routerRouteHumanName, getRouterRouteResourceIdentifierFromSchema, deleteRouterRoute, routerRouteLockKey)
+)
+
+// resourceCloudscaleRouterRoute manages a single static route of a router.
+// Routes are backed by the static_routes list of the router; the destination
+// identifies a route within that list.
+func resourceCloudscaleRouterRoute() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceCloudscaleRouterRouteCreate,
+		ReadContext:   resourceCloudscaleRouterRouteRead,
+		DeleteContext: resourceCloudscaleRouterRouteDelete,
+
+		Importer: &schema.ResourceImporter{
+			StateContext: func(
+				ctx context.Context,
+				d *schema.ResourceData,
+				m any,
+			) ([]*schema.ResourceData, error) {
+				// The destination contains dots itself, so only split on the first one.
+				routerID, destination, ok := strings.Cut(d.Id(), ".")
+				if !ok || routerID == "" || destination == "" {
+					return nil, fmt.Errorf("invalid import id %q. Expecting {router_uuid}.{destination}", d.Id())
+				}
+				err := d.Set("router_uuid", routerID)
+				if err != nil {
+					return nil, err
+				}
+				d.SetId(destination)
+				return []*schema.ResourceData{d}, nil
+			},
+		},
+		Schema:        getRouterRouteSchema(),
+		CustomizeDiff: validateRouterRoute,
+	}
+}
+
+type RouterRouteResourceIdentifier struct {
+	Destination string
+	RouterID    string
+}
+
+func getRouterRouteResourceIdentifierFromSchema(d *schema.ResourceData) RouterRouteResourceIdentifier {
+	return RouterRouteResourceIdentifier{
+		Destination: d.Id(),
+		RouterID:    d.Get("router_uuid").(string),
+	}
+}
+
+func getRouterRouteSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"router_uuid": {
+			Type:     schema.TypeString,
+			Required: true,
+			ForceNew: true,
+		},
+		"destination": {
+			// The destination is the ID of the route, so it must be written
+			// the way the API returns it.
+			Type:         schema.TypeString,
+			Required:     true,
+			ForceNew:     true,
+			ValidateFunc: validateCanonicalCIDR,
+		},
+		"nexthop": {
+			Type:         schema.TypeString,
+			Required:     true,
+			ForceNew:     true,
+			ValidateFunc: validation.IsIPAddress,
+		},
+	}
+}
+
+// routerStaticRoute is a static route as stored on the router. The SDK
+// doesn't know about static routes, so they are read and written with requests
+// of our own.
+type routerStaticRoute struct {
+	Destination string `json:"destination"`
+	Nexthop     string `json:"nexthop"`
+}
+
+type routerStaticRoutes struct {
+	StaticRoutes []routerStaticRoute `json:"static_routes"`
+}
+
+func getRouterStaticRoutes(ctx context.Context, client *cloudscale.Client, routerUUID string) ([]routerStaticRoute, error) {
+	router := new(routerStaticRoutes)
+	if err := getFromAPI(ctx, client, "v1/routers/"+routerUUID, router); err != nil {
+		return nil, err
+	}
+	return router.StaticRoutes, nil
+}
+
+func setRouterStaticRoutes(ctx context.Context, client *cloudscale.Client, routerUUID string, routes []routerStaticRoute) error {
+	// An empty list removes all routes, null would be rejected.
+	body := &routerStaticRoutes{StaticRoutes: append([]routerStaticRoute{}, routes...)}
+	req, err := client.NewRequest(ctx, http.MethodPatch, "v1/routers/"+routerUUID, body)
+	if err != nil {
+		return err
+	}
+	return client.Do(ctx, req, nil)
+}
+
+// routerSubnetCIDRs returns the subnets the router has an address in, i.e.
+// the subnets it can reach a next hop in.
+func routerSubnetCIDRs(router *cloudscale.Router) []string {
+	var cidrs []string
+	for _, iface := range router.Interfaces {
+		for _, addr := range iface.Addresses {
+			if addr.Subnet.CIDR != "" {
+				cidrs = append(cidrs, addr.Subnet.CIDR)
+			}
+		}
+	}
+	return cidrs
+}
+
+func validateRouterRouteNexthop(nexthop string, cidrs []string) error {
+	ip := net.ParseIP(nexthop)
+	if ip == nil {
+		return fmt.Errorf("nexthop %q is not an IP address", nexthop)
+	}
+	for _, cidr := range cidrs {
+		_, subnet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			continue
+		}
+		if subnet.Contains(ip) {
+			return nil
+		}
+	}
+	if len(cidrs) == 0 {
+		return fmt.Errorf("nexthop %s is not reachable: the router has no interface with an address, create a cloudscale_interface first", nexthop)
+	}
+	return fmt.Errorf("nexthop %s is not in any subnet of the router's interfaces (%s)", nexthop, strings.Join(cidrs, ", "))
+}
+
+// validateRouterRoute checks the nexthop against the subnets of the router when
+// planning. A router without addresses is skipped, as its interfaces may be
+// created in the same apply; createRouterRoute checks again in that case.
+func validateRouterRoute(ctx context.Context, d *schema.ResourceDiff, meta any) error {
+	if !d.HasChanges("router_uuid", "nexthop") || !d.NewValueKnown("router_uuid") || !d.NewValueKnown("nexthop") {
+		return nil
+	}
+
+	client := meta.(*providerMeta).Client
+	routerUUID := d.Get("router_uuid").(string)
+	router, err := client.Routers.Get(ctx, routerUUID)
+	if err != nil {
+		return fmt.Errorf("error retrieving router (%s): %s", routerUUID, err)
+	}
+	cidrs := routerSubnetCIDRs(router)
+	if len(cidrs) == 0 {
+		return nil
+	}
+	return validateRouterRouteNexthop(d.Get("nexthop").(string), cidrs)
+}
+
+func createRouterRoute(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+	client := meta.(*providerMeta).Client
+
+	routerUUID := d.Get("router_uuid").(string)
+	route := routerStaticRoute{
+		Destination: d.Get("destination").(string),
+		Nexthop:     d.Get("nexthop").(string),
+	}
+
+	router, err := client.Routers.Get(ctx, routerUUID)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error retrieving router (%s): %s", routerUUID, err))
+	}
+	if err := validateRouterRouteNexthop(route.Nexthop, routerSubnetCIDRs(router)); err != nil {
+		return diag.FromErr(err)
+	}
+
+	routes, err := getRouterStaticRoutes(ctx, client, routerUUID)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error retrieving the routes of router (%s): %s", routerUUID, err))
+	}
+	for _, existing := range routes {
+		if existing.Destination == route.Destination {
+			return diag.FromErr(fmt.Errorf("router (%s) already has a route to %s", routerUUID, route.Destination))
+		}
+	}
+
+	log.Printf("[DEBUG] Router route create configuration: %#v", route)
+
+	err = setRouterStaticRoutes(ctx, client, routerUUID, append(routes, route))
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error creating router route: %s", err))
+	}
+
+	d.SetId(route.Destination)
+
+	log.Printf("[INFO] Router route ID %s", d.Id())
+	return resourceCloudscaleRouterRouteRead(ctx, d, meta)
+}
+
+func gatherRouterRouteResourceData(route *routerStaticRoute) ResourceDataRaw {
+	m := make(map[string]any)
+	m["id"] = route.Destination
+	m["destination"] = route.Destination
+	m["nexthop"] = route.Nexthop
+	return m
+}
+
+func readRouterRoute(ctx context.Context, rId RouterRouteResourceIdentifier, meta any) (*routerStaticRoute, error) {
+	client := meta.(*providerMeta).Client
+
+	routes, err := getRouterStaticRoutes(ctx, client, rId.RouterID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range routes {
+		if routes[i].Destination == rId.Destination {
+			return &routes[i], nil
+		}
+	}
+
+	// The route no longer exists on the router; signal a 404 so it is
+	// removed from state via CheckDeleted.
+	return nil, &cloudscale.ErrorResponse{StatusCode: http.StatusNotFound}
+}
+
+func deleteRouterRoute(ctx context.Context, rId RouterRouteResourceIdentifier, meta any) error {
+	client := meta.(*providerMeta).Client
+
+	routes, err := getRouterStaticRoutes(ctx, client, rId.RouterID)
+	if err != nil {
+		return err
+	}
+	remaining := make([]routerStaticRoute, 0, len(routes))
+	for _, route := range routes {
+		if route.Destination != rId.Destination {
+			remaining = append(remaining, route)
+		}
+	}
+	if len(remaining) == len(routes) {
+		return nil
+	}
+	return setRouterStaticRoutes(ctx, client, rId.RouterID, remaining)
+}
@@ -0,0 +1,177 @@
+package cloudscale
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/cloudscale-ch/cloudscale-go-sdk/v10"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestValidateRouterRouteNexthop(t *testing.T) {
+	cidrs := []string{"10.11.12.0/24", "2001:db8::/64"}
+
+	for _, nexthop := range []string{"10.11.12.20", "2001:db8::20"} {
+		if err := validateRouterRouteNexthop(nexthop, cidrs); err != nil {
+			t.Errorf("%s: unexpected error: %s", nexthop, err)
+		}
+	}
+	for _, nexthop := range []string{"10.11.13.20", "2001:db9::20", "not-an-ip"} {
+		if err := validateRouterRouteNexthop(nexthop, cidrs); err == nil {
+			t.Errorf("%s: expected an error", nexthop)
+		}
+	}
+	if err := validateRouterRouteNexthop("10.11.12.20", nil); err == nil {
+		t.Error("expected an error for a router without interfaces")
+	}
+}
+
+func TestRouterRouteDestinationIsCanonical(t *testing.T) {
+	validate := getRouterRouteSchema()["destination"].ValidateFunc
+
+	for _, destination := range []string{"192.168.0.0/16", "2001:db8::/48"} {
+		if _, errs := validate(destination, "destination"); len(errs) != 0 {
+			t.Errorf("%s: unexpected errors: %v", destination, errs)
+		}
+	}
+	// The destination is the ID, any other spelling would never match the
+	// route returned by the API.
+	for _, destination := range []string{"192.168.0.1/16", "2001:DB8::/48", "2001:db8:0::/48"} {
+		if _, errs := validate(destination, "destination"); len(errs) == 0 {
+			t.Errorf("%s: expected an error", destination)
+		}
+	}
+}
+
+func TestDeleteRouterRoute(t *testing.T) {
+	const routerUUID = "2f2f8b1b-2d8c-4a4d-8f5e-6b8e5c4c1f0a"
+
+	var body []byte
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/routers/"+routerUUID, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = io.WriteString(w, `{"static_routes": [
+				{"destination": "192.168.0.0/16", "nexthop": "10.11.12.20"},
+				{"destination": "172.16.0.0/12", "nexthop": "10.11.12.21"}
+			]}`)
+		case http.MethodPatch:
+			var err error
+			body, err = io.ReadAll(r.Body)
+			if err != nil {
+				t.Errorf("reading request body: %s", err)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected %s request", r.Method)
+		}
+	})
+	client := testClient(t, mux)
+
+	rId := RouterRouteResourceIdentifier{Destination: "192.168.0.0/16", RouterID: routerUUID}
+	if err := deleteRouterRoute(context.Background(), rId, client); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var actual routerStaticRoutes
+	if err := json.Unmarshal(body, &actual); err != nil {
+		t.Fatalf("decoding request body %q: %s", body, err)
+	}
+	expected := routerStaticRoutes{StaticRoutes: []routerStaticRoute{
+		{Destination: "172.16.0.0/12", Nexthop: "10.11.12.21"},
+	}}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("got %+v, want %+v", actual, expected)
+	}
+}
+
+func TestAccCloudscaleRouterRoute_Basic(t *testing.T) {
+	var router cloudscale.Router
+
+	rInt := acctest.RandInt()
+	resourceName := "cloudscale_router_route.vpn"
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudscaleRouterRouteDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: routerRouteConfig_basic(rInt, "10.11.12.20"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudscaleRouterExists("cloudscale_router.basic", &router),
+					resource.TestCheckResourceAttrPair(
+						resourceName, "router_uuid", "cloudscale_router.basic", "id"),
+					resource.TestCheckResourceAttr(
+						resourceName, "id", "192.168.0.0/16"),
+					resource.TestCheckResourceAttr(
+						resourceName, "destination", "192.168.0.0/16"),
+					resource.TestCheckResourceAttr(
+						resourceName, "nexthop", "10.11.12.20"),
+				),
+			},
+			{
+				ResourceName:      resourceName,
+				ImportState:       true,
+				ImportStateVerify: true,
+				ImportStateIdFunc: func(state *terraform.State) (string, error) {
+					return fmt.Sprintf("%s.%s", router.UUID, "192.168.0.0/16"), nil
+				},
+			},
+			{
+				Config:      routerRouteConfig_basic(rInt, "10.11.13.20"),
+				ExpectError: regexp.MustCompile(`not in any subnet of the router's interfaces`),
+			},
+		},
+	})
+}
+
+func testAccCheckCloudscaleRouterRouteDestroy(s *terraform.State) error {
+	client := testAccProvider.Meta().(*providerMeta).Client
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "cloudscale_router_route" {
+			continue
+		}
+
+		routerID := rs.Primary.Attributes["router_uuid"]
+		routes, err := getRouterStaticRoutes(context.Background(), client, routerID)
+		if err != nil {
+			// Parent router is gone -> route is gone too.
+			if cerr, ok := errors.AsType[*cloudscale.ErrorResponse](err); ok && cerr.StatusCode == http.StatusNotFound {
+				continue
+			}
+			return fmt.Errorf("error retrieving router %s: %s", routerID, err)
+		}
+
+		for _, route := range routes {
+			if route.Destination == rs.Primary.ID {
+				return fmt.Errorf("route %s still exists on router %s", rs.Primary.ID, routerID)
+			}
+		}
+	}
+
+	return nil
+}
+
+func routerRouteConfig_basic(rInt int, nexthop string) string {
+	return interfaceConfig_basic(rInt) + fmt.Sprintf(`
+
+resource "cloudscale_router_route" "vpn" {
+  router_uuid = cloudscale_router.basic.id
+  destination = "192.168.0.0/16"
+  nexthop     = "%s"
+
+  depends_on = [cloudscale_interface.basic]
+}`, nexthop)
+}
@@ -4,11 +4,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 
 	"github.com/cloudscale-ch/cloudscale-go-sdk/v10"
@@ -29,7 +33,7 @@ func testSweepRouters(region string) error {
 		return err
 	}
 
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 
 	routers, err := client.Routers.List(context.Background())
 	if err != nil {
@@ -93,6 +97,43 @@ func TestAccCloudscaleRouter_Basic(t *testing.T) {
 	})
 }
 
+func TestAccCloudscaleRouter_UpdateInPlace(t *testing.T) {
+	var afterCreate, afterUpdate cloudscale.Router
+
+	rInt := acctest.RandInt()
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudscaleRouterDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: routerConfig_basic(rInt),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudscaleRouterExists("cloudscale_router.basic", &afterCreate),
+				),
+			},
+			{
+				Config: routerConfig_updated(rInt),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudscaleRouterExists("cloudscale_router.basic", &afterUpdate),
+					resource.TestCheckResourceAttr(
+						"cloudscale_router.basic", "name", fmt.Sprintf("terraform-%d-renamed", rInt)),
+					resource.TestCheckResourceAttr(
+						"cloudscale_router.basic", "internet_gateway", "false"),
+					resource.TestCheckResourceAttr(
+						"cloudscale_router.basic", "internet_gateway_addresses.#", "0"),
+					resource.TestCheckResourceAttr(
+						"cloudscale_router.basic", "tags.%", "1"),
+					resource.TestCheckResourceAttr(
+						"cloudscale_router.basic", "tags.my-foo", "foo"),
+					testAccCheckRouterIsSame(t, &afterCreate, &afterUpdate),
+				),
+			},
+		},
+	})
+}
+
 func TestAccCloudscaleRouter_import_basic(t *testing.T) {
 	var router cloudscale.Router
 
@@ -121,7 +162,7 @@ func TestAccCloudscaleRouter_import_basic(t *testing.T) {
 }
 
 func testAccCheckCloudscaleRouterDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*cloudscale.Client)
+	client := testAccProvider.Meta().(*providerMeta).Client
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "cloudscale_router" {
@@ -146,6 +187,117 @@ func testAccCheckCloudscaleRouterDestroy(s *terraform.State) error {
 	return nil
 }
 
+func testAccCheckRouterIsSame(t *testing.T,
+	before, after *cloudscale.Router) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		if before.UUID != after.UUID {
+			t.Fatalf("Not expected a change of Router IDs got=%s, expected=%s",
+				after.UUID, before.UUID)
+		}
+		return nil
+	}
+}
+
+// shortenWaits makes waitForStatus and waitForDeleted poll without delay.
+func shortenWaits(t *testing.T) {
+	t.Helper()
+	delay, minTimeout, interval := waitForStatusDelay, waitForStatusMinTimeout, waitForDeletedInterval
+	waitForStatusDelay, waitForStatusMinTimeout, waitForDeletedInterval = 0, 10*time.Millisecond, 10*time.Millisecond
+	t.Cleanup(func() {
+		waitForStatusDelay, waitForStatusMinTimeout, waitForDeletedInterval = delay, minTimeout, interval
+	})
+}
+
+// routerStatusHandler answers the nth GET of the router with the nth status,
+// repeating the last one. An empty status answers with 404.
+func routerStatusHandler(t *testing.T, uuid string, statuses ...string) (http.Handler, *atomic.Int32) {
+	requests := new(atomic.Int32)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/routers/"+uuid, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("unexpected %s request", r.Method)
+		}
+		status := statuses[min(int(requests.Add(1)), len(statuses))-1]
+		w.Header().Set("Content-Type", "application/json")
+		if status == "" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"detail": "Not found."}`)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"uuid": %q, "status": %q}`, uuid, status)
+	})
+	return mux, requests
+}
+
+func TestWaitForRouterRunning(t *testing.T) {
+	const routerUUID = "2f2f8b1b-2d8c-4a4d-8f5e-6b8e5c4c1f0a"
+	shortenWaits(t)
+	timeout := time.Minute
+
+	waitForRunning := func(t *testing.T, handler http.Handler) error {
+		d := schema.TestResourceDataRaw(t, getRouterSchema(RESOURCE), map[string]any{})
+		d.SetId(routerUUID)
+		refreshFunc := newRouterRefreshFunc(context.Background(), d, "status", testClient(t, handler))
+		_, err := waitForStatus(context.Background(), []string{"changing"}, "running", &timeout, refreshFunc)
+		return err
+	}
+
+	t.Run("waits while changing", func(t *testing.T) {
+		handler, requests := routerStatusHandler(t, routerUUID, "changing", "changing", "running")
+		if err := waitForRunning(t, handler); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if actual := requests.Load(); actual != 3 {
+			t.Errorf("got %d requests, want 3", actual)
+		}
+	})
+
+	t.Run("fails on an unexpected status", func(t *testing.T) {
+		handler, requests := routerStatusHandler(t, routerUUID, "changing", "errored")
+		err := waitForRunning(t, handler)
+		if err == nil || !strings.Contains(err.Error(), "unexpected state 'errored'") {
+			t.Fatalf("got %v, want an unexpected state error", err)
+		}
+		if actual := requests.Load(); actual != 2 {
+			t.Errorf("got %d requests, want 2", actual)
+		}
+	})
+}
+
+func TestWaitForRouterDeleted(t *testing.T) {
+	const routerUUID = "2f2f8b1b-2d8c-4a4d-8f5e-6b8e5c4c1f0a"
+	shortenWaits(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	t.Run("waits until the router is gone", func(t *testing.T) {
+		handler, requests := routerStatusHandler(t, routerUUID, "changing", "changing", "")
+		if err := waitForRouterDeleted(ctx, routerUUID, testClient(t, handler)); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if actual := requests.Load(); actual != 3 {
+			t.Errorf("got %d requests, want 3", actual)
+		}
+	})
+
+	t.Run("fails on API errors", func(t *testing.T) {
+		requests := new(atomic.Int32)
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = io.WriteString(w, `{"detail": "Internal server error."}`)
+		})
+		err := waitForRouterDeleted(ctx, routerUUID, testClient(t, handler))
+		if err == nil || !strings.Contains(err.Error(), "delete refresh") {
+			t.Fatalf("got %v, want a refresh error", err)
+		}
+		if actual := requests.Load(); actual != 1 {
+			t.Errorf("got %d requests, want 1", actual)
+		}
+	})
+}
+
 func routerConfig_basic(rInt int) string {
 	return fmt.Sprintf(`
 resource "cloudscale_router" "basic" {
@@ -155,6 +307,18 @@ resource "cloudscale_router" "basic" {
 }`, rInt)
 }
 
+func routerConfig_updated(rInt int) string {
+	return fmt.Sprintf(`
+resource "cloudscale_router" "basic" {
+  name             = "terraform-%d-renamed"
+  zone_slug        = "rma1"
+  internet_gateway = false
+  tags = {
+    my-foo = "foo"
+  }
+}`, rInt)
+}
+
 func routerConfig_baseline(count int, rInt int) string {
 	return fmt.Sprintf(`
 resource "cloudscale_router" "basic" {
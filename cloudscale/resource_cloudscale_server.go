@@ -2,12 +2,18 @@ package cloudscale
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"reflect"
+	"sort"
 	"time"
 
 	"github.com/cloudscale-ch/cloudscale-go-sdk/v10"
+	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
@@ -25,16 +31,24 @@ func resourceCloudscaleServer() *schema.Resource {
 		CreateContext: resourceCloudscaleServerCreate,
 		ReadContext:   resourceCloudscaleServerRead,
 		UpdateContext: resourceCloudscaleServerUpdate,
-		DeleteContext: resourceCloudscaleServerDelete,
+		DeleteContext: resourceCloudscaleServerShutdownAndDelete,
 
 		Schema: getServerSchema(RESOURCE),
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(5 * time.Minute),
 			Update: schema.DefaultTimeout(1 * time.Hour),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
 		},
 		Importer: &schema.ResourceImporter{
 			StateContext: resourceCloudscaleServerImport,
 		},
+		CustomizeDiff: customdiff.Sequence(
+			validateServerSlugs,
+			resolveServerInterfaceNetworks,
+		),
+
+		SchemaVersion:  2,
+		StateUpgraders: resourceCloudscaleServerStateUpgraders(),
 	}
 }
 
@@ -79,11 +93,6 @@ func getServerSchema(t SchemaType) map[string]*schema.Schema {
 						Type:     schema.TypeString,
 						Computed: true,
 					},
-					"device_path": {
-						Type:       schema.TypeString,
-						Computed:   true,
-						Deprecated: "This attribute is always null and will be removed in a future major version.",
-					},
 					"uuid": {
 						Type:     schema.TypeString,
 						Computed: true,
@@ -104,12 +113,25 @@ func getServerSchema(t SchemaType) map[string]*schema.Schema {
 			Type:     schema.TypeString,
 			Computed: true,
 		},
+		"public_ipv4_reverse_ptr": {
+			Type:     schema.TypeString,
+			Optional: t.isResource(),
+			Computed: true,
+		},
+		"public_ipv6_reverse_ptr": {
+			Type:     schema.TypeString,
+			Optional: t.isResource(),
+			Computed: true,
+		},
 		"private_ipv4_address": {
 			Type:     schema.TypeString,
 			Computed: true,
 		},
 		"interfaces": {
-			Type: schema.TypeList,
+			// A set keyed by network (see serverInterfaceHash), so neither the
+			// order in the config nor the order returned by the API matters.
+			Type: schema.TypeSet,
+			Set:  serverInterfaceHash,
 			Elem: &schema.Resource{
 				Schema: map[string]*schema.Schema{
 					"type": {
@@ -237,11 +259,49 @@ func getServerSchema(t SchemaType) map[string]*schema.Schema {
 			ForceNew: true,
 		}
 		m["password"] = &schema.Schema{
-			Type:      schema.TypeString,
-			Optional:  true,
-			Elem:      &schema.Schema{Type: schema.TypeString},
-			ForceNew:  true,
-			Sensitive: true,
+			Type:          schema.TypeString,
+			Optional:      true,
+			Elem:          &schema.Schema{Type: schema.TypeString},
+			ForceNew:      true,
+			Sensitive:     true,
+			ConflictsWith: []string{"password_wo", "generate_password"},
+		}
+		m["password_wo"] = &schema.Schema{
+			Type:          schema.TypeString,
+			Optional:      true,
+			WriteOnly:     true,
+			Sensitive:     true,
+			ConflictsWith: []string{"password", "generate_password"},
+		}
+		m["password_wo_version"] = &schema.Schema{
+			// password_wo is never stored, so changing it can't be detected.
+			// Changing this value replaces the server with the new password.
+			Type:     schema.TypeInt,
+			Optional: true,
+			ForceNew: true,
+		}
+		m["generate_password"] = &schema.Schema{
+			Type:          schema.TypeBool,
+			Optional:      true,
+			ForceNew:      true,
+			ConflictsWith: []string{"password", "password_wo"},
+			RequiredWith:  []string{"pgp_key"},
+		}
+		m["pgp_key"] = &schema.Schema{
+			Type:         schema.TypeString,
+			Optional:     true,
+			ForceNew:     true,
+			RequiredWith: []string{"generate_password"},
+			ValidateFunc: func(v any, k string) ([]string, []error) {
+				if _, err := readPGPPublicKey(v.(string)); err != nil {
+					return nil, []error{fmt.Errorf("%q: %s", k, err)}
+				}
+				return nil, nil
+			},
+		}
+		m["encrypted_password"] = &schema.Schema{
+			Type:     schema.TypeString,
+			Computed: true,
 		}
 		m["volume_size_gb"] = &schema.Schema{
 			Type:     schema.TypeInt,
@@ -288,6 +348,16 @@ func getServerSchema(t SchemaType) map[string]*schema.Schema {
 			Elem:     &schema.Schema{Type: schema.TypeString},
 			ForceNew: true,
 		}
+		m["graceful_shutdown_timeout"] = &schema.Schema{
+			Type:     schema.TypeString,
+			Optional: true,
+			ValidateFunc: func(v any, k string) ([]string, []error) {
+				if _, err := time.ParseDuration(v.(string)); err != nil {
+					return nil, []error{fmt.Errorf("%q must be a duration such as \"2m\": %s", k, err)}
+				}
+				return nil, nil
+			},
+		}
 	}
 	return m
 }
@@ -296,7 +366,7 @@ func createServer(ctx context.Context, d *schema.ResourceData, meta any) diag.Di
 	timeout := d.Timeout(schema.TimeoutCreate)
 	startTime := time.Now()
 
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 
 	opts := &cloudscale.ServerRequest{
 		Name:   d.Get("name").(string),
@@ -333,6 +403,27 @@ func createServer(ctx context.Context, d *schema.ResourceData, meta any) diag.Di
 		opts.Password = attr.(string)
 	}
 
+	passwordWO, diags := d.GetRawConfigAt(cty.GetAttrPath("password_wo"))
+	if diags.HasError() {
+		return diags
+	}
+	if passwordWO.Type().Equals(cty.String) && !passwordWO.IsNull() {
+		opts.Password = passwordWO.AsString()
+	}
+
+	encryptedPassword := ""
+	if d.Get("generate_password").(bool) {
+		password, err := generateServerPassword()
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		encryptedPassword, err = encryptPassword(password, d.Get("pgp_key").(string))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		opts.Password = password
+	}
+
 	if attr, ok := d.GetOk("bulk_volume_size_gb"); ok {
 		opts.BulkVolumeSizeGB = attr.(int)
 	}
@@ -366,7 +457,11 @@ func createServer(ctx context.Context, d *schema.ResourceData, meta any) diag.Di
 	}
 	opts.Tags = TagsFromState(d)
 
-	log.Printf("[DEBUG] Server create configuration: %#v", opts)
+	logOpts := *opts
+	if logOpts.Password != "" {
+		logOpts.Password = "<sensitive>"
+	}
+	log.Printf("[DEBUG] Server create configuration: %#v", logOpts)
 
 	server, err := client.Servers.Create(ctx, opts)
 	if err != nil {
@@ -374,6 +469,7 @@ func createServer(ctx context.Context, d *schema.ResourceData, meta any) diag.Di
 	}
 
 	d.SetId(server.UUID)
+	d.Set("encrypted_password", encryptedPassword)
 
 	log.Printf("[INFO] Server ID %s", d.Id())
 
@@ -389,6 +485,15 @@ func createServer(ctx context.Context, d *schema.ResourceData, meta any) diag.Di
 		return diag.FromErr(fmt.Errorf("error waiting for SSH host keys (%s) to be available: %s", d.Id(), err))
 	}
 
+	ipv4ReversePtr := d.Get("public_ipv4_reverse_ptr").(string)
+	ipv6ReversePtr := d.Get("public_ipv6_reverse_ptr").(string)
+	if ipv4ReversePtr != "" || ipv6ReversePtr != "" {
+		err := updateServerReversePtrs(ctx, client, server.UUID, ipv4ReversePtr, ipv6ReversePtr)
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("error setting the reverse pointers of server (%s): %s", server.UUID, err))
+		}
+	}
+
 	if originalStatus == "stopped" {
 		updateRequest := &cloudscale.ServerUpdateRequest{
 			Status: originalStatus,
@@ -416,44 +521,173 @@ func createImageOption(d *schema.ResourceData) string {
 	return d.Get("image_uuid").(string)
 }
 
+// serverInterfaceKey identifies an interface regardless of its position: a
+// server has at most one public interface and one interface per private
+// network. Private interfaces whose network is not known yet are identified
+// by their subnet instead, so they don't collapse into one.
+func serverInterfaceKey(interfaceType string, networkUUID string, subnetUUID string) string {
+	if interfaceType == "public" {
+		return "public"
+	}
+	if networkUUID == "" && subnetUUID != "" {
+		return "private/subnet/" + subnetUUID
+	}
+	return "private/" + networkUUID
+}
+
+// serverInterfaceKeyFromMap is serverInterfaceKey for an element of the
+// interfaces set.
+func serverInterfaceKeyFromMap(intr map[string]any) string {
+	subnetUUID := ""
+	if addresses, _ := intr["addresses"].([]any); len(addresses) > 0 && addresses[0] != nil {
+		subnetUUID, _ = addresses[0].(map[string]any)["subnet_uuid"].(string)
+	}
+	return serverInterfaceKey(intr["type"].(string), intr["network_uuid"].(string), subnetUUID)
+}
+
+func serverInterfaceHash(v any) int {
+	return schema.HashString(serverInterfaceKeyFromMap(v.(map[string]any)))
+}
+
+func serverInterfacesByKey(interfaces *schema.Set) map[string]map[string]any {
+	result := make(map[string]map[string]any, interfaces.Len())
+	for _, raw := range interfaces.List() {
+		intr := raw.(map[string]any)
+		result[serverInterfaceKeyFromMap(intr)] = intr
+	}
+	return result
+}
+
+// sortedServerInterfaceKeys orders new interfaces deterministically: the
+// public interface first, then the private ones by network or subnet UUID.
+func sortedServerInterfaceKeys(interfaces map[string]map[string]any) []string {
+	keys := make([]string, 0, len(interfaces))
+	for key := range interfaces {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i] == "public" || keys[j] == "public" {
+			return keys[i] == "public"
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// resolveServerInterfaceNetworks fills in network_uuid for private interfaces
+// that are only configured by subnet. The set is keyed by network, so without
+// this the interface would hash differently in the config than in the state,
+// and every plan would show it as replaced.
+func resolveServerInterfaceNetworks(ctx context.Context, d *schema.ResourceDiff, meta any) error {
+	if !d.NewValueKnown("interfaces") {
+		return nil
+	}
+	client := meta.(*providerMeta).Client
+
+	interfaces := d.Get("interfaces").(*schema.Set).List()
+	resolved := false
+	for _, raw := range interfaces {
+		intr := raw.(map[string]any)
+		if intr["type"].(string) == "public" || intr["network_uuid"].(string) != "" {
+			continue
+		}
+		addresses := intr["addresses"].([]any)
+		if len(addresses) == 0 {
+			continue
+		}
+		subnetUUID := addresses[0].(map[string]any)["subnet_uuid"].(string)
+		if subnetUUID == "" {
+			// Not known yet, e.g. the subnet is created in the same apply.
+			continue
+		}
+		subnet, err := client.Subnets.Get(ctx, subnetUUID)
+		if err != nil {
+			return fmt.Errorf("error retrieving subnet (%s) of server interface: %s", subnetUUID, err)
+		}
+		intr["network_uuid"] = subnet.Network.UUID
+		resolved = true
+	}
+	if !resolved {
+		return nil
+	}
+	return d.SetNew("interfaces", interfaces)
+}
+
+// createInterfaceOptions builds the interfaces of a new server.
 func createInterfaceOptions(d *schema.ResourceData) []cloudscale.ServerInterfaceRequest {
-	interfacesCount := d.Get("interfaces.#").(int)
-	result := make([]cloudscale.ServerInterfaceRequest, interfacesCount)
-	for i := 0; i < interfacesCount; i++ {
-		prefix := fmt.Sprintf("interfaces.%d", i)
-		intType := d.Get(prefix + ".type").(string)
-
-		if intType == "public" {
-			result[i] = cloudscale.ServerInterfaceRequest{
-				Network: "public",
-			}
-		} else {
-			result[i] = createPrivateInterfaceOptions(d, prefix)
+	interfaces := serverInterfacesByKey(d.Get("interfaces").(*schema.Set))
+	result := make([]cloudscale.ServerInterfaceRequest, 0, len(interfaces))
+	for _, key := range sortedServerInterfaceKeys(interfaces) {
+		result = append(result, newInterfaceRequest(interfaces[key], true))
+	}
+	return result
+}
+
+// updateInterfaceOptions builds the complete list of interfaces the server
+// should have after the update. Interfaces the server keeps stay in their
+// current order, so the guest sees its NICs in the same slots; new interfaces
+// are appended. Only interfaces whose addresses changed send addresses, all
+// others keep the addresses they have.
+func updateInterfaceOptions(o *schema.Set, n *schema.Set, server *cloudscale.Server) []cloudscale.ServerInterfaceRequest {
+	oldInterfaces := serverInterfacesByKey(o)
+	newInterfaces := serverInterfacesByKey(n)
+
+	result := make([]cloudscale.ServerInterfaceRequest, 0, len(newInterfaces))
+	for _, intr := range server.Interfaces {
+		key := serverInterfaceKey(intr.Type, intr.Network.UUID, "")
+		wanted, ok := newInterfaces[key]
+		if !ok {
+			// The interface was removed.
+			continue
 		}
+		delete(newInterfaces, key)
+		result = append(result, newInterfaceRequest(wanted, serverInterfaceAddressesChanged(oldInterfaces[key], wanted)))
+	}
+	for _, key := range sortedServerInterfaceKeys(newInterfaces) {
+		result = append(result, newInterfaceRequest(newInterfaces[key], true))
 	}
 	return result
 }
 
-func createPrivateInterfaceOptions(d *schema.ResourceData, prefix string) cloudscale.ServerInterfaceRequest {
-	result := cloudscale.ServerInterfaceRequest{}
+func serverInterfaceAddressesChanged(old map[string]any, new map[string]any) bool {
+	if old == nil {
+		return true
+	}
+	if old["no_address"].(bool) != new["no_address"].(bool) {
+		return true
+	}
+	// Compare what can be configured only, the other fields are computed.
+	return !reflect.DeepEqual(
+		createAddressesOptions(old["addresses"].([]any)),
+		createAddressesOptions(new["addresses"].([]any)),
+	)
+}
 
-	addressKey := prefix + ".addresses"
-	if d.HasChange(addressKey) {
-		addresses := d.Get(addressKey).([]any)
-		if len(addresses) > 0 {
-			addresses := createAddressesOptions(addresses)
-			result.Addresses = &addresses
+// newInterfaceRequest builds the request for one interface. Addresses are only
+// sent if withAddresses is set; otherwise the interface keeps its addresses.
+func newInterfaceRequest(intr map[string]any, withAddresses bool) cloudscale.ServerInterfaceRequest {
+	if intr["type"].(string) == "public" {
+		return cloudscale.ServerInterfaceRequest{
+			Network: "public",
 		}
-		// we don't need to update the network
+	}
+
+	result := cloudscale.ServerInterfaceRequest{}
+
+	addresses := intr["addresses"].([]any)
+	if withAddresses && len(addresses) > 0 {
+		addresses := createAddressesOptions(addresses)
+		result.Addresses = &addresses
+		// the subnets determine the network
 		return result
 	}
 
-	networkUUID := d.Get(prefix + ".network_uuid").(string)
+	networkUUID := intr["network_uuid"].(string)
 	if networkUUID != "" {
 		result.Network = networkUUID
 	}
 
-	if d.Get(prefix + ".no_address").(bool) {
+	if intr["no_address"].(bool) {
 		result.Addresses = &[]cloudscale.AddressRequest{}
 	}
 
@@ -546,12 +780,14 @@ func gatherServerResourceData(server *cloudscale.Server) ResourceDataRaw {
 
 	m["public_ipv4_address"] = findIPv4AddrByType(server, "public")
 	m["public_ipv6_address"] = findIPv6AddrByType(server, "public")
+	m["public_ipv4_reverse_ptr"] = findPublicReversePtr(server, 4)
+	m["public_ipv6_reverse_ptr"] = findPublicReversePtr(server, 6)
 	m["private_ipv4_address"] = findIPv4AddrByType(server, "private")
 	return m
 }
 
 func readServer(ctx context.Context, rId GenericResourceIdentifier, meta any) (*cloudscale.Server, error) {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.Servers.Get(ctx, rId.Id)
 }
 
@@ -560,7 +796,7 @@ func resourceCloudscaleServerUpdate(ctx context.Context, d *schema.ResourceData,
 	startTime := time.Now()
 	remainingTime := timeout - time.Since(startTime)
 
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	id := d.Id()
 
 	wantedStatus := d.Get("status").(string)
@@ -655,14 +891,26 @@ func resourceCloudscaleServerUpdate(ctx context.Context, d *schema.ResourceData,
 	}
 
 	if d.HasChange("interfaces") {
-		interfaceRequests := createInterfaceOptions(d)
+		server, err := client.Servers.Get(ctx, id)
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("Error retrieving server (%s) for update %s", id, err))
+		}
+		o, n := d.GetChange("interfaces")
+		interfaceRequests := updateInterfaceOptions(o.(*schema.Set), n.(*schema.Set), server)
 		updateRequest := &cloudscale.ServerUpdateRequest{Interfaces: &interfaceRequests}
-		err := client.Servers.Update(ctx, id, updateRequest)
+		err = client.Servers.Update(ctx, id, updateRequest)
 		if err != nil {
 			return diag.FromErr(fmt.Errorf("Error changing the Server (%s) interfaces (%s) ", id, err))
 		}
 	}
 
+	if d.HasChanges("public_ipv4_reverse_ptr", "public_ipv6_reverse_ptr") {
+		err := updateServerReversePtrs(ctx, client, id, d.Get("public_ipv4_reverse_ptr").(string), d.Get("public_ipv6_reverse_ptr").(string))
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("Error setting the reverse pointers of the Server (%s) (%s) ", id, err))
+		}
+	}
+
 	if d.HasChange("tags") {
 		updateRequest := &cloudscale.ServerUpdateRequest{}
 		updateRequest.Tags = TagsFromState(d)
@@ -675,13 +923,100 @@ func resourceCloudscaleServerUpdate(ctx context.Context, d *schema.ResourceData,
 	return resourceCloudscaleServerRead(ctx, d, meta)
 }
 
+// resourceCloudscaleServerShutdownAndDelete stops the server before deleting it
+// when graceful_shutdown_timeout is set, so the guest OS gets a chance to shut
+// down its services cleanly instead of being killed.
+func resourceCloudscaleServerShutdownAndDelete(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+	if attr, ok := d.GetOk("graceful_shutdown_timeout"); ok {
+		timeout, err := time.ParseDuration(attr.(string))
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("invalid graceful_shutdown_timeout %q: %s", attr, err))
+		}
+		err = shutdownServer(ctx, d, meta, timeout)
+		if err != nil {
+			return diag.FromErr(CheckDeleted(d, err, "Error shutting down server"))
+		}
+		if d.Id() == "" {
+			// The server is already gone.
+			return nil
+		}
+	}
+	return resourceCloudscaleServerDelete(ctx, d, meta)
+}
+
+// shutdownServer requests the server to stop and waits up to timeout for it to
+// do so. Running out of time is not an error: the server gets deleted anyway.
+// The wait ends in time to delete the server within the delete timeout.
+func shutdownServer(ctx context.Context, d *schema.ResourceData, meta any, timeout time.Duration) error {
+	client := meta.(*providerMeta).Client
+	id := d.Id()
+
+	server, err := client.Servers.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if server.Status == cloudscale.ServerStopped {
+		return nil
+	}
+
+	if remaining := capToDeadline(ctx, timeout); remaining < timeout {
+		log.Printf("[WARN] graceful_shutdown_timeout %s exceeds the delete timeout, waiting only %s for server (%s) to stop", timeout, max(remaining, 0), id)
+		timeout = remaining
+	}
+	if timeout <= 0 {
+		return nil
+	}
+
+	log.Printf("[INFO] Stopping server (%s) before deletion, waiting up to %s", id, timeout)
+	updateRequest := &cloudscale.ServerUpdateRequest{
+		Status: cloudscale.ServerStopped,
+	}
+	err = client.Servers.Update(ctx, id, updateRequest)
+	if err != nil {
+		return err
+	}
+
+	_, err = waitForStatus(ctx, []string{"changing", "running"}, "stopped", &timeout, newServerRefreshFunc(ctx, d, "status", meta))
+	var timeoutErr *resource.TimeoutError
+	if errors.As(err, &timeoutErr) {
+		log.Printf("[WARN] Server (%s) did not stop within %s, deleting it anyway", id, timeout)
+		return nil
+	}
+	return err
+}
+
 func deleteServer(ctx context.Context, rId GenericResourceIdentifier, meta any) error {
-	client := meta.(*cloudscale.Client)
-	return client.Servers.Delete(ctx, rId.Id)
+	client := meta.(*providerMeta).Client
+
+	if err := client.Servers.Delete(ctx, rId.Id); err != nil {
+		return err
+	}
+	// The server keeps its network ports until it is fully gone. Wait for that,
+	// so that deleting the networks it was attached to doesn't fail.
+	return waitForServerDeleted(ctx, rId.Id, meta)
+}
+
+func waitForServerDeleted(ctx context.Context, id string, meta any) error {
+	client := meta.(*providerMeta).Client
+	err := waitForDeleted(ctx, func() (exists bool, err error) {
+		server, err := client.Servers.Get(ctx, id)
+		if err != nil {
+			if errorResponse, ok := err.(*cloudscale.ErrorResponse); ok && errorResponse.StatusCode == http.StatusNotFound {
+				return false, nil // gone
+			}
+			return false, fmt.Errorf("error retrieving server (%s) (delete refresh) %s", id, err)
+		}
+		log.Printf("[INFO] Status is %s", server.Status)
+		return true, nil // still exists
+	})
+	if err != nil {
+		return fmt.Errorf("error waiting for server (%s) to be deleted: %s", id, err)
+	}
+	return nil
 }
 
 func newServerRefreshFunc(ctx context.Context, d *schema.ResourceData, attribute string, meta any) resource.StateRefreshFunc {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return func() (any, string, error) {
 		id := d.Id()
 
@@ -753,6 +1088,19 @@ func findIPv6AddrByType(s *cloudscale.Server, addrType string) string {
 	return ""
 }
 
+func findPublicReversePtr(s *cloudscale.Server, version int) string {
+	for _, interf := range s.Interfaces {
+		if interf.Type == "public" {
+			for _, addr := range interf.Addresses {
+				if addr.Version == version {
+					return addr.ReversePtr
+				}
+			}
+		}
+	}
+	return ""
+}
+
 func findIPv4AddrByType(s *cloudscale.Server, addrType string) string {
 	for _, interf := range s.Interfaces {
 		if interf.Type == addrType {
@@ -68,7 +68,7 @@ func getServerGroupSchema(t SchemaType) map[string]*schema.Schema {
 }
 
 func createServerGroup(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 
 	opts := &cloudscale.ServerGroupRequest{
 		Name: d.Get("name").(string),
@@ -106,12 +106,12 @@ func gatherServerGroupResourceData(serverGroup *cloudscale.ServerGroup) Resource
 }
 
 func readServerGroup(ctx context.Context, rId GenericResourceIdentifier, meta any) (*cloudscale.ServerGroup, error) {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.ServerGroups.Get(ctx, rId.Id)
 }
 
 func updateServerGroup(ctx context.Context, rId GenericResourceIdentifier, meta any, updateRequest *cloudscale.ServerGroupRequest) error {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.ServerGroups.Update(ctx, rId.Id, updateRequest)
 }
 
@@ -135,6 +135,6 @@ func gatherServerGroupUpdateRequest(d *schema.ResourceData) []*cloudscale.Server
 }
 
 func deleteServerGroup(ctx context.Context, rId GenericResourceIdentifier, meta any) error {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.ServerGroups.Delete(ctx, rId.Id)
 }
@@ -29,7 +29,7 @@ func testSweepServerGroups(region string) error {
 		return err
 	}
 
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 
 	serverGroups, err := client.ServerGroups.List(context.Background())
 	if err != nil {
@@ -290,7 +290,7 @@ func testAccCheckServerGroupIsSame(t *testing.T, before, after *cloudscale.Serve
 }
 
 func testAccCheckCloudscaleServerGroupDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*cloudscale.Client)
+	client := testAccProvider.Meta().(*providerMeta).Client
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "cloudscale_server_group" {
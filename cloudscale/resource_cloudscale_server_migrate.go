@@ -0,0 +1,159 @@
+package cloudscale
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// The schemas in this file are frozen copies of earlier versions of the
+// cloudscale_server schema. They describe the state that the matching state
+// upgrader receives, so they must not follow later changes to
+// getServerSchema.
+//
+// To change the schema in a way existing state can't be read with, freeze the
+// current schema as resourceCloudscaleServerVn, add an upgrader from version n
+// and bump SchemaVersion. Terraform runs the upgraders in order, so each one
+// only has to handle the step from its own version to the next.
+
+func resourceCloudscaleServerStateUpgraders() []schema.StateUpgrader {
+	return []schema.StateUpgrader{
+		{
+			Version: 0,
+			Type:    resourceCloudscaleServerV0().CoreConfigSchema().ImpliedType(),
+			Upgrade: upgradeServerStateV0,
+		},
+		{
+			Version: 1,
+			Type:    resourceCloudscaleServerV1().CoreConfigSchema().ImpliedType(),
+			Upgrade: upgradeServerStateV1,
+		},
+	}
+}
+
+// resourceCloudscaleServerV0 is the server schema before interfaces became a
+// set keyed by network.
+func resourceCloudscaleServerV0() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"name":        {Type: schema.TypeString, Required: true},
+			"zone_slug":   {Type: schema.TypeString, Optional: true, Computed: true},
+			"flavor_slug": {Type: schema.TypeString, Required: true},
+			"image_slug":  {Type: schema.TypeString, Optional: true, Computed: true},
+			"image_uuid":  {Type: schema.TypeString, Optional: true},
+			"href":        {Type: schema.TypeString, Computed: true},
+			"volumes": {
+				Type: schema.TypeList,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"type":        {Type: schema.TypeString, Computed: true},
+						"device_path": {Type: schema.TypeString, Computed: true},
+						"uuid":        {Type: schema.TypeString, Computed: true},
+						"size_gb":     {Type: schema.TypeInt, Computed: true},
+					},
+				},
+				Computed: true,
+			},
+			"public_ipv4_address":  {Type: schema.TypeString, Computed: true},
+			"public_ipv6_address":  {Type: schema.TypeString, Computed: true},
+			"private_ipv4_address": {Type: schema.TypeString, Computed: true},
+			"interfaces": {
+				Type: schema.TypeList,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"type":         {Type: schema.TypeString, Required: true},
+						"network_uuid": {Type: schema.TypeString, Optional: true, Computed: true},
+						"network_name": {Type: schema.TypeString, Computed: true},
+						"network_href": {Type: schema.TypeString, Computed: true},
+						"addresses": {
+							Type: schema.TypeList,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"version":       {Type: schema.TypeInt, Computed: true},
+									"address":       {Type: schema.TypeString, Optional: true, Computed: true},
+									"prefix_length": {Type: schema.TypeInt, Computed: true},
+									"gateway":       {Type: schema.TypeString, Computed: true},
+									"reverse_ptr":   {Type: schema.TypeString, Computed: true},
+									"subnet_uuid":   {Type: schema.TypeString, Optional: true, Computed: true},
+									"subnet_cidr":   {Type: schema.TypeString, Computed: true},
+									"subnet_href":   {Type: schema.TypeString, Computed: true},
+								},
+							},
+							Optional: true,
+							Computed: true,
+						},
+						"no_address": {Type: schema.TypeBool, Optional: true},
+					},
+				},
+				Optional: true,
+				Computed: true,
+			},
+			"ssh_fingerprints": {Type: schema.TypeList, Elem: &schema.Schema{Type: schema.TypeString}, Computed: true},
+			"ssh_host_keys":    {Type: schema.TypeList, Elem: &schema.Schema{Type: schema.TypeString}, Computed: true},
+			"status":           {Type: schema.TypeString, Optional: true},
+			"tags":             {Type: schema.TypeMap, Elem: &schema.Schema{Type: schema.TypeString}, Optional: true},
+			"server_groups": {
+				Type: schema.TypeList,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"href": {Type: schema.TypeString, Computed: true},
+						"uuid": {Type: schema.TypeString, Computed: true},
+						"name": {Type: schema.TypeString, Computed: true},
+					},
+				},
+				Computed: true,
+			},
+			"ssh_keys":                       {Type: schema.TypeSet, Elem: &schema.Schema{Type: schema.TypeString}, Optional: true},
+			"password":                       {Type: schema.TypeString, Optional: true, Sensitive: true},
+			"password_wo":                    {Type: schema.TypeString, Optional: true, Sensitive: true},
+			"password_wo_version":            {Type: schema.TypeInt, Optional: true},
+			"generate_password":              {Type: schema.TypeBool, Optional: true},
+			"pgp_key":                        {Type: schema.TypeString, Optional: true},
+			"encrypted_password":             {Type: schema.TypeString, Computed: true},
+			"volume_size_gb":                 {Type: schema.TypeInt, Optional: true},
+			"bulk_volume_size_gb":            {Type: schema.TypeInt, Optional: true},
+			"user_data":                      {Type: schema.TypeString, Optional: true},
+			"use_public_network":             {Type: schema.TypeBool, Optional: true},
+			"use_private_network":            {Type: schema.TypeBool, Optional: true},
+			"use_ipv6":                       {Type: schema.TypeBool, Optional: true},
+			"allow_stopping_for_update":      {Type: schema.TypeBool, Optional: true},
+			"skip_waiting_for_ssh_host_keys": {Type: schema.TypeBool, Optional: true},
+			"server_group_ids":               {Type: schema.TypeSet, Elem: &schema.Schema{Type: schema.TypeString}, Optional: true},
+			"graceful_shutdown_timeout":      {Type: schema.TypeString, Optional: true},
+		},
+	}
+}
+
+// upgradeServerStateV0 migrates interfaces from a list to a set. Both are
+// stored as JSON arrays, so the state is passed on unchanged: the next read
+// stores the interfaces keyed by network.
+func upgradeServerStateV0(_ context.Context, rawState map[string]any, _ any) (map[string]any, error) {
+	return rawState, nil
+}
+
+// resourceCloudscaleServerV1 is the server schema with interfaces keyed by
+// network, before volumes.device_path was removed.
+func resourceCloudscaleServerV1() *schema.Resource {
+	r := resourceCloudscaleServerV0()
+	r.Schema["interfaces"].Type = schema.TypeSet
+	r.Schema["public_ipv4_reverse_ptr"] = &schema.Schema{Type: schema.TypeString, Optional: true, Computed: true}
+	r.Schema["public_ipv6_reverse_ptr"] = &schema.Schema{Type: schema.TypeString, Optional: true, Computed: true}
+	return r
+}
+
+// upgradeServerStateV1 removes volumes.device_path, which was always null.
+func upgradeServerStateV1(_ context.Context, rawState map[string]any, _ any) (map[string]any, error) {
+	if rawState == nil {
+		return rawState, nil
+	}
+	volumes, ok := rawState["volumes"].([]any)
+	if !ok {
+		return rawState, nil
+	}
+	for _, v := range volumes {
+		if volume, ok := v.(map[string]any); ok {
+			delete(volume, "device_path")
+		}
+	}
+	return rawState, nil
+}
@@ -0,0 +1,80 @@
+package cloudscale
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func testServerStateV0() map[string]any {
+	return map[string]any{
+		"id": "server-uuid",
+		"volumes": []any{
+			map[string]any{"type": "ssd", "device_path": nil, "uuid": "volume-uuid", "size_gb": 10},
+		},
+		"interfaces": []any{
+			map[string]any{"type": "public", "network_uuid": "public-net"},
+			map[string]any{"type": "private", "network_uuid": "net-a"},
+		},
+	}
+}
+
+func TestUpgradeServerStateV0(t *testing.T) {
+	expected := testServerStateV0()
+
+	actual, err := upgradeServerStateV0(context.Background(), testServerStateV0(), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("got %#v, want %#v", actual, expected)
+	}
+}
+
+func TestUpgradeServerStateV1(t *testing.T) {
+	expected := testServerStateV0()
+	expected["volumes"] = []any{
+		map[string]any{"type": "ssd", "uuid": "volume-uuid", "size_gb": 10},
+	}
+
+	actual, err := upgradeServerStateV1(context.Background(), testServerStateV0(), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("got %#v, want %#v", actual, expected)
+	}
+
+	// A server without volumes has none in its state.
+	withoutVolumes := map[string]any{"id": "server-uuid", "volumes": nil}
+	if _, err := upgradeServerStateV1(context.Background(), withoutVolumes, nil); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func TestResourceCloudscaleServerStateUpgraders(t *testing.T) {
+	r := resourceCloudscaleServer()
+
+	// Every version before the current one needs an upgrader, in order.
+	if len(r.StateUpgraders) != r.SchemaVersion {
+		t.Fatalf("got %d upgraders for schema version %d", len(r.StateUpgraders), r.SchemaVersion)
+	}
+	for i, upgrader := range r.StateUpgraders {
+		if upgrader.Version != i {
+			t.Errorf("upgrader %d has version %d", i, upgrader.Version)
+		}
+	}
+
+	state := testServerStateV0()
+	for _, upgrader := range r.StateUpgraders {
+		var err error
+		state, err = upgrader.Upgrade(context.Background(), state, nil)
+		if err != nil {
+			t.Fatalf("upgrading from version %d: %s", upgrader.Version, err)
+		}
+	}
+	volume := state["volumes"].([]any)[0].(map[string]any)
+	if _, ok := volume["device_path"]; ok {
+		t.Error("device_path is still in the upgraded state")
+	}
+}
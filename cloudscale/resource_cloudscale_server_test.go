@@ -2,17 +2,24 @@ package cloudscale
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"reflect"
 	"regexp"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/cloudscale-ch/cloudscale-go-sdk/v10"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
@@ -31,7 +38,7 @@ func testSweepServers(region string) error {
 		return err
 	}
 
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 
 	servers, err := client.Servers.List(context.Background())
 	if err != nil {
@@ -72,8 +79,8 @@ func TestAccCloudscaleServer_Basic(t *testing.T) {
 						"cloudscale_server.basic", "flavor_slug", "flex-4-1"),
 					resource.TestCheckResourceAttr(
 						"cloudscale_server.basic", "image_slug", DefaultImageSlug),
-					resource.TestCheckResourceAttr(
-						"cloudscale_server.basic", "interfaces.0.type", "public"),
+					resource.TestCheckTypeSetElemNestedAttrs(
+						"cloudscale_server.basic", "interfaces.*", map[string]string{"type": "public"}),
 					resource.TestCheckResourceAttr(
 						"cloudscale_server.basic", "ssh_host_keys.#", "3"),
 					testAccCheckServerIp("cloudscale_server.basic"),
@@ -219,6 +226,56 @@ func TestAccCloudscaleServer_Password(t *testing.T) {
 	})
 }
 
+func TestAccCloudscaleServer_PasswordWriteOnly(t *testing.T) {
+	var afterCreate cloudscale.Server
+
+	rInt := acctest.RandInt()
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudscaleServerDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testServerPasswordWriteOnlyConfig(rInt),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudscaleServerExists("cloudscale_server.password", &afterCreate),
+					resource.TestCheckNoResourceAttr("cloudscale_server.password", "password_wo"),
+					resource.TestCheckResourceAttr("cloudscale_server.password", "password", ""),
+					resource.TestCheckResourceAttr("cloudscale_server.password", "password_wo_version", "1"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccCloudscaleServer_GeneratePassword(t *testing.T) {
+	var afterCreate cloudscale.Server
+
+	rInt := acctest.RandInt()
+	_, armoredKey, _ := testPGPEntity(t)
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudscaleServerDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config:      testServerGeneratePasswordWithoutKeyConfig(rInt),
+				ExpectError: regexp.MustCompile("all of `generate_password,pgp_key` must be specified"),
+			},
+			{
+				Config: testServerGeneratePasswordConfig(rInt, armoredKey),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudscaleServerExists("cloudscale_server.password", &afterCreate),
+					resource.TestCheckResourceAttrSet("cloudscale_server.password", "encrypted_password"),
+					resource.TestCheckResourceAttr("cloudscale_server.password", "password", ""),
+				),
+			},
+		},
+	})
+}
+
 func TestAccCloudscaleServer_Recreated(t *testing.T) {
 	var afterCreate, afterUpdate cloudscale.Server
 
@@ -276,8 +333,8 @@ func TestAccCloudscaleServer_PrivateNetwork(t *testing.T) {
 						"cloudscale_server.private", "name", fmt.Sprintf("terraform-%d", rInt)),
 					resource.TestCheckResourceAttr(
 						"cloudscale_server.private", "interfaces.#", "1"),
-					resource.TestCheckResourceAttr(
-						"cloudscale_server.private", "interfaces.0.type", "private"),
+					resource.TestCheckTypeSetElemNestedAttrs(
+						"cloudscale_server.private", "interfaces.*", map[string]string{"type": "private"}),
 					testAccCheckServerIp("cloudscale_server.private"),
 				),
 			},
@@ -330,6 +387,96 @@ func TestAccCloudscaleServer_UpdateNameAndFlavorAndVolumeSize(t *testing.T) {
 	})
 }
 
+func TestAccCloudscaleServer_GracefulShutdown(t *testing.T) {
+	var server cloudscale.Server
+
+	rInt := acctest.RandInt()
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudscaleServerDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCheckCloudscaleServerConfig_graceful_shutdown(rInt, "2m"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudscaleServerExists("cloudscale_server.basic", &server),
+					resource.TestCheckResourceAttr(
+						"cloudscale_server.basic", "graceful_shutdown_timeout", "2m"),
+					resource.TestCheckResourceAttr(
+						"cloudscale_server.basic", "status", "running"),
+				),
+			},
+			{
+				Config:      testAccCheckCloudscaleServerConfig_graceful_shutdown(rInt, "two minutes"),
+				ExpectError: regexp.MustCompile(`must be a duration`),
+			},
+		},
+	})
+}
+
+func TestAccCloudscaleServer_ReversePtr(t *testing.T) {
+	var server cloudscale.Server
+
+	rInt := acctest.RandInt()
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudscaleServerDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCheckCloudscaleServerConfig_reverse_ptr(rInt, "mail.example.com"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudscaleServerExists("cloudscale_server.basic", &server),
+					resource.TestCheckResourceAttr(
+						"cloudscale_server.basic", "public_ipv4_reverse_ptr", "mail.example.com"),
+					resource.TestCheckResourceAttr(
+						"cloudscale_server.basic", "public_ipv6_reverse_ptr", "mail6.example.com"),
+					resource.TestCheckTypeSetElemNestedAttrs("cloudscale_server.basic", "interfaces.*", map[string]string{
+						"type":                    "public",
+						"addresses.0.reverse_ptr": "mail.example.com",
+					}),
+				),
+			},
+			{
+				Config: testAccCheckCloudscaleServerConfig_reverse_ptr(rInt, "smtp.example.com"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudscaleServerExists("cloudscale_server.basic", &server),
+					resource.TestCheckResourceAttr(
+						"cloudscale_server.basic", "public_ipv4_reverse_ptr", "smtp.example.com"),
+					resource.TestCheckResourceAttr(
+						"cloudscale_server.basic", "public_ipv6_reverse_ptr", "mail6.example.com"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccCloudscaleServer_InvalidSlugs(t *testing.T) {
+	rInt := acctest.RandInt()
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudscaleServerDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccCheckCloudscaleServerConfig_slugs(rInt, "flex-4-l", DefaultImageSlug, "rma1"),
+				ExpectError: regexp.MustCompile(`flavor_slug: unknown flavor "flex-4-l", did you mean "flex-4-1"\?`),
+			},
+			{
+				Config:      testAccCheckCloudscaleServerConfig_slugs(rInt, "flex-4-1", DefaultImageSlug+"x", "rma1"),
+				ExpectError: regexp.MustCompile(`image_slug: unknown image`),
+			},
+			{
+				Config:      testAccCheckCloudscaleServerConfig_slugs(rInt, "flex-4-1", DefaultImageSlug, "rma2"),
+				ExpectError: regexp.MustCompile(`zone_slug: unknown zone "rma2"`),
+			},
+		},
+	})
+}
+
 func TestAccCloudscaleServer_import_basic(t *testing.T) {
 	var afterImport, afterUpdate cloudscale.Server
 
@@ -378,7 +525,7 @@ func TestAccCloudscaleServer_import_basic(t *testing.T) {
 }
 
 func testAccCheckCloudscaleServerDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*cloudscale.Client)
+	client := testAccProvider.Meta().(*providerMeta).Client
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "cloudscale_server" {
@@ -437,7 +584,7 @@ func testAccCheckServerIp(n string) resource.TestCheckFunc {
 			return fmt.Errorf("No Server ID is set")
 		}
 
-		client := testAccProvider.Meta().(*cloudscale.Client)
+		client := testAccProvider.Meta().(*providerMeta).Client
 
 		id := rs.Primary.ID
 
@@ -476,6 +623,23 @@ func testAccCheckServerIp(n string) resource.TestCheckFunc {
 	}
 }
 
+// testAccCheckCloudscaleServerInterfaceOrder checks the order of the private
+// networks attached to the server, as the guest sees them.
+func testAccCheckCloudscaleServerInterfaceOrder(server *cloudscale.Server, networkNames ...string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		var actual []string
+		for _, intr := range server.Interfaces {
+			if intr.Type == "private" {
+				actual = append(actual, intr.Network.Name)
+			}
+		}
+		if !reflect.DeepEqual(actual, networkNames) {
+			return fmt.Errorf("Bad interface order: got %v, expected %v", actual, networkNames)
+		}
+		return nil
+	}
+}
+
 func testAccCheckServerIsSame(t *testing.T,
 	before, after *cloudscale.Server) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
@@ -658,6 +822,43 @@ resource "cloudscale_server" "basic" {
 }`, rInt, DefaultImageSlug)
 }
 
+func testAccCheckCloudscaleServerConfig_graceful_shutdown(rInt int, timeout string) string {
+	return fmt.Sprintf(`
+resource "cloudscale_server" "basic" {
+  name                      = "terraform-%d"
+  flavor_slug               = "flex-4-1"
+  image_slug                = "%s"
+  volume_size_gb            = 10
+  graceful_shutdown_timeout = "%s"
+  ssh_keys                  = ["ecdsa-sha2-nistp256 AAAAE2VjZHNhLXNoYTItbmlzdHAyNTYAAAAIbmlzdHAyNTYAAABBBFEepRNW5hDct4AdJ8oYsb4lNP5E9XY5fnz3ZvgNCEv7m48+bhUjJXUPuamWix3zigp2lgJHC6SChI/okJ41GUY="]
+}`, rInt, DefaultImageSlug, timeout)
+}
+
+func testAccCheckCloudscaleServerConfig_reverse_ptr(rInt int, ipv4ReversePtr string) string {
+	return fmt.Sprintf(`
+resource "cloudscale_server" "basic" {
+  name                    = "terraform-%d"
+  flavor_slug             = "flex-4-1"
+  image_slug              = "%s"
+  volume_size_gb          = 10
+  public_ipv4_reverse_ptr = "%s"
+  public_ipv6_reverse_ptr = "mail6.example.com"
+  ssh_keys                = ["ecdsa-sha2-nistp256 AAAAE2VjZHNhLXNoYTItbmlzdHAyNTYAAAAIbmlzdHAyNTYAAABBBFEepRNW5hDct4AdJ8oYsb4lNP5E9XY5fnz3ZvgNCEv7m48+bhUjJXUPuamWix3zigp2lgJHC6SChI/okJ41GUY="]
+}`, rInt, DefaultImageSlug, ipv4ReversePtr)
+}
+
+func testAccCheckCloudscaleServerConfig_slugs(rInt int, flavorSlug string, imageSlug string, zoneSlug string) string {
+	return fmt.Sprintf(`
+resource "cloudscale_server" "basic" {
+  name           = "terraform-%d"
+  flavor_slug    = "%s"
+  image_slug     = "%s"
+  zone_slug      = "%s"
+  volume_size_gb = 10
+  ssh_keys       = ["ecdsa-sha2-nistp256 AAAAE2VjZHNhLXNoYTItbmlzdHAyNTYAAAAIbmlzdHAyNTYAAABBBFEepRNW5hDct4AdJ8oYsb4lNP5E9XY5fnz3ZvgNCEv7m48+bhUjJXUPuamWix3zigp2lgJHC6SChI/okJ41GUY="]
+}`, rInt, flavorSlug, imageSlug, zoneSlug)
+}
+
 func testServerPasswordConfig(rInt int) string {
 	return fmt.Sprintf(`
 resource "cloudscale_server" "password" {
@@ -670,3 +871,234 @@ resource "cloudscale_server" "password" {
   use_private_network       = true
 }`, rInt)
 }
+
+func testServerPasswordWriteOnlyConfig(rInt int) string {
+	return fmt.Sprintf(`
+resource "cloudscale_server" "password" {
+  name                      = "terraform-%d"
+  flavor_slug               = "flex-4-1"
+  image_slug                = "pfsense-2.7.0"
+  volume_size_gb            = 10
+  password_wo               = "rivella17"
+  password_wo_version       = 1
+  use_private_network       = true
+}`, rInt)
+}
+
+func testServerGeneratePasswordConfig(rInt int, pgpKey string) string {
+	return fmt.Sprintf(`
+resource "cloudscale_server" "password" {
+  name                      = "terraform-%d"
+  flavor_slug               = "flex-4-1"
+  image_slug                = "pfsense-2.7.0"
+  volume_size_gb            = 10
+  generate_password         = true
+  pgp_key                   = <<EOT
+%s
+EOT
+  use_private_network       = true
+}`, rInt, pgpKey)
+}
+
+func testServerGeneratePasswordWithoutKeyConfig(rInt int) string {
+	return fmt.Sprintf(`
+resource "cloudscale_server" "password" {
+  name                      = "terraform-%d"
+  flavor_slug               = "flex-4-1"
+  image_slug                = "pfsense-2.7.0"
+  volume_size_gb            = 10
+  generate_password         = true
+  use_private_network       = true
+}`, rInt)
+}
+
+func testServerInterfaceSet(interfaces ...map[string]any) *schema.Set {
+	elems := make([]any, len(interfaces))
+	for i, intr := range interfaces {
+		elem := map[string]any{
+			"type":         "private",
+			"network_uuid": "",
+			"addresses":    []any{},
+			"no_address":   false,
+		}
+		for k, v := range intr {
+			elem[k] = v
+		}
+		elems[i] = elem
+	}
+	return schema.NewSet(serverInterfaceHash, elems)
+}
+
+func TestServerInterfaceHash(t *testing.T) {
+	// Computed fields must not change the hash, or a refresh would show the
+	// interface as replaced.
+	interfaces := testServerInterfaceSet(
+		map[string]any{"network_uuid": "net-a"},
+		map[string]any{"network_uuid": "net-a", "network_name": "a", "addresses": []any{
+			map[string]any{"address": "10.0.0.5", "subnet_uuid": "subnet-a"},
+		}},
+	)
+	if interfaces.Len() != 1 {
+		t.Errorf("got %d interfaces, want 1", interfaces.Len())
+	}
+}
+
+func TestServerInterfaceHashSubnetOnly(t *testing.T) {
+	// Interfaces configured by subnet only have no network_uuid until it is
+	// resolved, they must not collapse into one element.
+	interfaces := testServerInterfaceSet(
+		map[string]any{"addresses": []any{
+			map[string]any{"address": "", "subnet_uuid": "subnet-a"},
+		}},
+		map[string]any{"addresses": []any{
+			map[string]any{"address": "", "subnet_uuid": "subnet-b"},
+		}},
+	)
+	if interfaces.Len() != 2 {
+		t.Fatalf("got %d interfaces, want 2", interfaces.Len())
+	}
+
+	actual := sortedServerInterfaceKeys(serverInterfacesByKey(interfaces))
+	expected := []string{"private/subnet/subnet-a", "private/subnet/subnet-b"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("got %v, want %v", actual, expected)
+	}
+}
+
+func TestSortedServerInterfaceKeys(t *testing.T) {
+	interfaces := serverInterfacesByKey(testServerInterfaceSet(
+		map[string]any{"network_uuid": "net-b"},
+		map[string]any{"type": "public"},
+		map[string]any{"network_uuid": "net-a"},
+	))
+
+	actual := sortedServerInterfaceKeys(interfaces)
+	expected := []string{"public", "private/net-a", "private/net-b"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("got %v, want %v", actual, expected)
+	}
+}
+
+func TestUpdateInterfaceOptions(t *testing.T) {
+	var server cloudscale.Server
+	err := json.Unmarshal([]byte(`{"interfaces": [
+		{"type": "private", "network": {"uuid": "net-b"}},
+		{"type": "public", "network": {"uuid": "public-net"}},
+		{"type": "private", "network": {"uuid": "net-a"}}
+	]}`), &server)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	addresses := []any{map[string]any{"address": "10.0.0.5", "subnet_uuid": "subnet-a"}}
+	o := testServerInterfaceSet(
+		map[string]any{"network_uuid": "net-b"},
+		map[string]any{"type": "public"},
+		map[string]any{"network_uuid": "net-a", "addresses": addresses},
+	)
+	n := testServerInterfaceSet(
+		map[string]any{"network_uuid": "net-d"},
+		map[string]any{"network_uuid": "net-c"},
+		map[string]any{"network_uuid": "net-a", "addresses": addresses},
+		map[string]any{"network_uuid": "net-b", "no_address": true},
+	)
+
+	actual := updateInterfaceOptions(o, n, &server)
+	expected := []cloudscale.ServerInterfaceRequest{
+		// Kept interfaces stay in place, the public one is removed.
+		{Network: "net-b", Addresses: &[]cloudscale.AddressRequest{}},
+		{Network: "net-a"},
+		// New interfaces are appended in a stable order.
+		{Network: "net-c"},
+		{Network: "net-d"},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("got %+v, want %+v", actual, expected)
+	}
+}
+
+// serverDeletionHandler serves a running server until it is deleted and
+// records the requests that stop or delete it.
+func serverDeletionHandler(t *testing.T, uuid string) (http.Handler, func() []string) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var requests []string
+	deleted := false
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/servers/"+uuid, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodGet:
+			if deleted {
+				http.Error(w, `{"detail": "Not found."}`, http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = fmt.Fprintf(w, `{"uuid": %q, "status": "running"}`, uuid)
+		case http.MethodPatch:
+			body, _ := io.ReadAll(r.Body)
+			if strings.Contains(string(body), `"status":"stopped"`) {
+				requests = append(requests, "stop")
+			} else {
+				requests = append(requests, "update")
+			}
+			w.WriteHeader(http.StatusNoContent)
+		case http.MethodDelete:
+			requests = append(requests, "delete")
+			deleted = true
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	return mux, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(requests)
+	}
+}
+
+func TestResourceCloudscaleServerShutdownAndDelete(t *testing.T) {
+	shortenWaits(t)
+	const uuid = "server-1"
+
+	t.Run("deletes the server after the shutdown timed out", func(t *testing.T) {
+		handler, requests := serverDeletionHandler(t, uuid)
+		client := testClient(t, handler)
+		d := schema.TestResourceDataRaw(t, getServerSchema(RESOURCE), map[string]any{
+			"graceful_shutdown_timeout": "50ms",
+		})
+		d.SetId(uuid)
+
+		if diags := resourceCloudscaleServerShutdownAndDelete(context.Background(), d, client); diags.HasError() {
+			t.Fatalf("unexpected error: %v", diags)
+		}
+		if got, want := requests(), []string{"stop", "delete"}; !slices.Equal(got, want) {
+			t.Errorf("requests = %v, want %v", got, want)
+		}
+	})
+
+	// A graceful_shutdown_timeout beyond the delete timeout must not use up
+	// the time needed to delete the server.
+	t.Run("ends the shutdown wait before the delete timeout", func(t *testing.T) {
+		reserve := deadlineReserve
+		deadlineReserve = 200 * time.Millisecond
+		t.Cleanup(func() { deadlineReserve = reserve })
+
+		handler, requests := serverDeletionHandler(t, uuid)
+		client := testClient(t, handler)
+		d := schema.TestResourceDataRaw(t, getServerSchema(RESOURCE), map[string]any{
+			"graceful_shutdown_timeout": "10m",
+		})
+		d.SetId(uuid)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		if diags := resourceCloudscaleServerShutdownAndDelete(ctx, d, client); diags.HasError() {
+			t.Fatalf("unexpected error: %v", diags)
+		}
+		if got, want := requests(), []string{"stop", "delete"}; !slices.Equal(got, want) {
+			t.Errorf("requests = %v, want %v", got, want)
+		}
+	})
+}
@@ -4,11 +4,13 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net"
 	"time"
 
 	"github.com/cloudscale-ch/cloudscale-go-sdk/v10"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 const subnetHumanName = "subnet"
@@ -30,17 +32,24 @@ func resourceCloudscaleSubnet() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
-		Schema: getSubnetSchema(RESOURCE),
+		Schema:        getSubnetSchema(RESOURCE),
+		CustomizeDiff: validateSubnet,
 	}
 }
 
 func getSubnetSchema(t SchemaType) map[string]*schema.Schema {
+	var cidrValidateFunc, ipAddressValidateFunc schema.SchemaValidateFunc
+	if t.isResource() {
+		cidrValidateFunc = validateCanonicalCIDR
+		ipAddressValidateFunc = validation.IsIPAddress
+	}
 	m := map[string]*schema.Schema{
 		"cidr": {
-			Type:     schema.TypeString,
-			Required: t.isResource(),
-			Optional: t.isDataSource(),
-			ForceNew: true,
+			Type:         schema.TypeString,
+			Required:     t.isResource(),
+			Optional:     t.isDataSource(),
+			ForceNew:     true,
+			ValidateFunc: cidrValidateFunc,
 		},
 		"network_uuid": {
 			Type:     schema.TypeString,
@@ -49,13 +58,17 @@ func getSubnetSchema(t SchemaType) map[string]*schema.Schema {
 			Computed: true,
 		},
 		"gateway_address": {
-			Type:     schema.TypeString,
-			Computed: true,
-			Optional: true,
+			Type:         schema.TypeString,
+			Computed:     true,
+			Optional:     true,
+			ValidateFunc: ipAddressValidateFunc,
 		},
 		"dns_servers": {
-			Type:     schema.TypeList,
-			Elem:     &schema.Schema{Type: schema.TypeString},
+			Type: schema.TypeList,
+			Elem: &schema.Schema{
+				Type:         schema.TypeString,
+				ValidateFunc: ipAddressValidateFunc,
+			},
 			Computed: true,
 			Optional: t.isResource(),
 		},
@@ -89,8 +102,77 @@ func getSubnetSchema(t SchemaType) map[string]*schema.Schema {
 	return m
 }
 
+// validateCanonicalCIDR accepts CIDRs without host bits, e.g. 10.11.12.0/24
+// but not 10.11.12.1/24, which the API would store differently.
+func validateCanonicalCIDR(v any, k string) ([]string, []error) {
+	value := v.(string)
+	_, ipNet, err := net.ParseCIDR(value)
+	if err != nil {
+		return nil, []error{fmt.Errorf("expected %s to be a CIDR, got %q: %s", k, value, err)}
+	}
+	if ipNet.String() != value {
+		return nil, []error{fmt.Errorf("expected %s to be a CIDR without host bits, got %q, did you mean %q?", k, value, ipNet.String())}
+	}
+	return nil, nil
+}
+
+// validateSubnet checks that the gateway lies in the subnet and that the
+// subnet doesn't overlap with the other subnets of its network.
+func validateSubnet(ctx context.Context, d *schema.ResourceDiff, meta any) error {
+	if !d.NewValueKnown("cidr") {
+		return nil
+	}
+	cidr := d.Get("cidr").(string)
+	_, subnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		// Reported by the validation of cidr.
+		return nil
+	}
+
+	if d.NewValueKnown("gateway_address") {
+		if gateway := net.ParseIP(d.Get("gateway_address").(string)); gateway != nil && !subnet.Contains(gateway) {
+			return fmt.Errorf("gateway_address %s is not in the subnet %s", gateway, cidr)
+		}
+	}
+
+	// The CIDR can only change when the subnet is created or replaced.
+	if d.Id() != "" && !d.HasChanges("cidr", "network_uuid") {
+		return nil
+	}
+	if !d.NewValueKnown("network_uuid") {
+		return nil
+	}
+	networkUUID := d.Get("network_uuid").(string)
+	if networkUUID == "" {
+		return nil
+	}
+
+	client := meta.(*providerMeta).Client
+	network, err := client.Networks.Get(ctx, networkUUID)
+	if err != nil {
+		return fmt.Errorf("error retrieving network (%s): %s", networkUUID, err)
+	}
+	return validateSubnetOverlap(d.Id(), subnet, network.Subnets)
+}
+
+func validateSubnetOverlap(uuid string, subnet *net.IPNet, others []cloudscale.SubnetStub) error {
+	for _, other := range others {
+		if other.UUID == uuid {
+			continue
+		}
+		_, otherNet, err := net.ParseCIDR(other.CIDR)
+		if err != nil {
+			continue
+		}
+		if subnet.Contains(otherNet.IP) || otherNet.Contains(subnet.IP) {
+			return fmt.Errorf("cidr %s overlaps with %s of the subnet %s in the same network", subnet, other.CIDR, other.UUID)
+		}
+	}
+	return nil
+}
+
 func createSubnet(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 
 	opts := &cloudscale.SubnetCreateRequest{
 		CIDR: d.Get("cidr").(string),
@@ -150,12 +232,12 @@ func gatherSubnetResourceData(subnet *cloudscale.Subnet) ResourceDataRaw {
 }
 
 func readSubnet(ctx context.Context, rId GenericResourceIdentifier, meta any) (*cloudscale.Subnet, error) {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.Subnets.Get(ctx, rId.Id)
 }
 
 func updateSubnet(ctx context.Context, rId GenericResourceIdentifier, meta any, updateRequest *cloudscale.SubnetUpdateRequest) error {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.Subnets.Update(ctx, rId.Id, updateRequest)
 }
 
@@ -195,7 +277,7 @@ func gatherSubnetUpdateRequests(d *schema.ResourceData) []*cloudscale.SubnetUpda
 }
 
 func deleteSubnet(ctx context.Context, rId GenericResourceIdentifier, meta any) error {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	// sending the next request immediately can cause errors, since the port cleanup process is still ongoing
 	time.Sleep(5 * time.Second)
 	return client.Subnets.Delete(ctx, rId.Id)
@@ -3,6 +3,7 @@ package cloudscale
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"regexp"
 	"testing"
@@ -191,14 +192,17 @@ func TestAccCloudscaleSubnet_ServerWithPublicAndPrivate(t *testing.T) {
 					testAccCheckCloudscaleSubnetExists("cloudscale_subnet.basic", &subnet),
 					testAccCheckCloudscaleServerExists("cloudscale_server.basic", &server),
 					resource.TestCheckResourceAttr("cloudscale_server.basic", "interfaces.#", "2"),
-					resource.TestCheckResourceAttr("cloudscale_server.basic", "interfaces.0.type", "public"),
-					resource.TestCheckResourceAttr("cloudscale_server.basic", "interfaces.0.addresses.#", "2"),
-					resource.TestCheckResourceAttr("cloudscale_server.basic", "interfaces.1.type", "private"),
-					resource.TestCheckResourceAttr("cloudscale_server.basic", "interfaces.1.network_name", fmt.Sprintf("terraform-%d", rInt1)),
-					resource.TestCheckResourceAttr("cloudscale_server.basic", "interfaces.1.addresses.#", "1"),
-					resource.TestCheckResourceAttrSet("cloudscale_server.basic", "interfaces.1.addresses.0.subnet_uuid"),
-					resource.TestCheckResourceAttr("cloudscale_server.basic", "interfaces.1.addresses.0.subnet_cidr", "10.11.12.0/24"),
-					resource.TestCheckResourceAttrSet("cloudscale_server.basic", "interfaces.1.addresses.0.subnet_href"),
+					resource.TestCheckTypeSetElemNestedAttrs("cloudscale_server.basic", "interfaces.*", map[string]string{
+						"type":        "public",
+						"addresses.#": "2",
+					}),
+					resource.TestCheckTypeSetElemNestedAttrs("cloudscale_server.basic", "interfaces.*", map[string]string{
+						"type":                    "private",
+						"network_name":            fmt.Sprintf("terraform-%d", rInt1),
+						"addresses.#":             "1",
+						"addresses.0.subnet_cidr": "10.11.12.0/24",
+					}),
+					resource.TestCheckTypeSetElemAttrPair("cloudscale_server.basic", "interfaces.*.addresses.0.subnet_uuid", "cloudscale_subnet.basic", "id"),
 				),
 			},
 			{
@@ -208,15 +212,18 @@ func TestAccCloudscaleSubnet_ServerWithPublicAndPrivate(t *testing.T) {
 					testAccCheckCloudscaleSubnetExists("cloudscale_subnet.basic", &subnet),
 					testAccCheckCloudscaleServerExists("cloudscale_server.basic", &server),
 					resource.TestCheckResourceAttr("cloudscale_server.basic", "interfaces.#", "2"),
-					resource.TestCheckResourceAttr("cloudscale_server.basic", "interfaces.0.type", "public"),
-					resource.TestCheckResourceAttr("cloudscale_server.basic", "interfaces.0.addresses.#", "2"),
-					resource.TestCheckResourceAttr("cloudscale_server.basic", "interfaces.1.type", "private"),
-					resource.TestCheckResourceAttr("cloudscale_server.basic", "interfaces.1.network_name", fmt.Sprintf("terraform-%d", rInt1)),
-					resource.TestCheckResourceAttr("cloudscale_server.basic", "interfaces.1.addresses.#", "1"),
-					resource.TestCheckResourceAttr("cloudscale_server.basic", "interfaces.1.addresses.0.address", "10.11.12.13"),
-					resource.TestCheckResourceAttrSet("cloudscale_server.basic", "interfaces.1.addresses.0.subnet_uuid"),
-					resource.TestCheckResourceAttr("cloudscale_server.basic", "interfaces.1.addresses.0.subnet_cidr", "10.11.12.0/24"),
-					resource.TestCheckResourceAttrSet("cloudscale_server.basic", "interfaces.1.addresses.0.subnet_href"),
+					resource.TestCheckTypeSetElemNestedAttrs("cloudscale_server.basic", "interfaces.*", map[string]string{
+						"type":        "public",
+						"addresses.#": "2",
+					}),
+					resource.TestCheckTypeSetElemNestedAttrs("cloudscale_server.basic", "interfaces.*", map[string]string{
+						"type":                    "private",
+						"network_name":            fmt.Sprintf("terraform-%d", rInt1),
+						"addresses.#":             "1",
+						"addresses.0.address":     "10.11.12.13",
+						"addresses.0.subnet_cidr": "10.11.12.0/24",
+					}),
+					resource.TestCheckTypeSetElemAttrPair("cloudscale_server.basic", "interfaces.*.addresses.0.subnet_uuid", "cloudscale_subnet.basic", "id"),
 				),
 			},
 		},
@@ -245,6 +252,80 @@ func TestAccCloudscaleSubnet_ValidationError(t *testing.T) {
 	})
 }
 
+func TestAccCloudscaleSubnet_PlanValidation(t *testing.T) {
+	rInt := acctest.RandInt()
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudscaleSubnetDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config:      subnetconfigValidation(rInt, "10.11.12.1/24", ""),
+				ExpectError: regexp.MustCompile(`without host bits, got "10.11.12.1/24", did you mean "10.11.12.0/24"`),
+			},
+			{
+				Config:      subnetconfigValidation(rInt, "10.11.12.0/24", `gateway_address = "10.11.13.1"`),
+				ExpectError: regexp.MustCompile(`gateway_address 10.11.13.1 is not in the subnet 10.11.12.0/24`),
+			},
+			{
+				Config:      subnetconfigValidation(rInt, "10.11.12.0/24", `dns_servers = ["10.11.12.1", "dns.example.com"]`),
+				ExpectError: regexp.MustCompile(`to contain a valid IP`),
+			},
+			{
+				Config: subnetconfigMinimal(rInt),
+			},
+			{
+				Config: subnetconfigMinimal(rInt) + `
+resource "cloudscale_subnet" "overlapping" {
+  cidr         = "10.11.12.128/25"
+  network_uuid = cloudscale_network.basic.id
+}`,
+				ExpectError: regexp.MustCompile(`cidr 10.11.12.128/25 overlaps with 10.11.12.0/24 of the subnet`),
+			},
+		},
+	})
+}
+
+func TestValidateCanonicalCIDR(t *testing.T) {
+	for _, cidr := range []string{"10.11.12.0/24", "192.168.0.0/16", "2001:db8::/64"} {
+		if _, errs := validateCanonicalCIDR(cidr, "cidr"); len(errs) != 0 {
+			t.Errorf("%s: unexpected errors: %v", cidr, errs)
+		}
+	}
+	for _, cidr := range []string{"10.11.12.1/24", "10.11.12.0", "10.11.12.0/33", "2001:db8::1/64", "not-a-cidr"} {
+		if _, errs := validateCanonicalCIDR(cidr, "cidr"); len(errs) == 0 {
+			t.Errorf("%s: expected an error", cidr)
+		}
+	}
+}
+
+func TestValidateSubnetOverlap(t *testing.T) {
+	others := []cloudscale.SubnetStub{
+		{UUID: "self", CIDR: "10.11.12.0/24"},
+		{UUID: "other", CIDR: "10.11.14.0/23"},
+	}
+
+	for cidr, overlaps := range map[string]bool{
+		"10.11.12.0/24":   false,
+		"10.11.13.0/24":   false,
+		"10.11.15.128/25": true,
+		"10.11.0.0/16":    true,
+		"10.12.0.0/16":    false,
+	} {
+		_, subnet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = validateSubnetOverlap("self", subnet, others)
+		if overlaps && err == nil {
+			t.Errorf("%s: expected an overlap", cidr)
+		} else if !overlaps && err != nil {
+			t.Errorf("%s: unexpected error: %s", cidr, err)
+		}
+	}
+}
+
 func TestAccCloudscaleSubnet_ServerAndMultipleSubnets(t *testing.T) {
 	count := 2
 	networks := make([]cloudscale.Network, count, count)
@@ -273,11 +354,13 @@ func TestAccCloudscaleSubnet_ServerAndMultipleSubnets(t *testing.T) {
 					testAccCheckCloudscaleServerExists("cloudscale_server.web-worker01", &server),
 					testAccCheckCloudscaleAddressOnSubnet(&server, &subnets[0], 0, 0),
 					resource.TestCheckResourceAttr("cloudscale_server.web-worker01", "interfaces.#", "1"),
-					resource.TestCheckResourceAttr("cloudscale_server.web-worker01", "interfaces.0.type", "private"),
-					resource.TestCheckResourceAttr("cloudscale_server.web-worker01", "interfaces.0.network_name", fmt.Sprintf("terraform-%d-0", rInt1)),
-					resource.TestCheckResourceAttr("cloudscale_server.web-worker01", "interfaces.0.addresses.#", "1"),
-					resource.TestCheckResourceAttr("cloudscale_server.web-worker01", "interfaces.0.addresses.0.address", "10.2.0.124"),
-					resource.TestCheckResourceAttr("cloudscale_server.web-worker01", "interfaces.0.addresses.0.subnet_cidr", "10.2.0.0/24"),
+					resource.TestCheckTypeSetElemNestedAttrs("cloudscale_server.web-worker01", "interfaces.*", map[string]string{
+						"type":                    "private",
+						"network_name":            fmt.Sprintf("terraform-%d-0", rInt1),
+						"addresses.#":             "1",
+						"addresses.0.address":     "10.2.0.124",
+						"addresses.0.subnet_cidr": "10.2.0.0/24",
+					}),
 				),
 			},
 			{
@@ -293,11 +376,13 @@ func TestAccCloudscaleSubnet_ServerAndMultipleSubnets(t *testing.T) {
 					testAccCheckCloudscaleServerExists("cloudscale_server.web-worker01", &server),
 					testAccCheckCloudscaleAddressOnSubnet(&server, &subnets[1], 0, 0),
 					resource.TestCheckResourceAttr("cloudscale_server.web-worker01", "interfaces.#", "1"),
-					resource.TestCheckResourceAttr("cloudscale_server.web-worker01", "interfaces.0.type", "private"),
-					resource.TestCheckResourceAttr("cloudscale_server.web-worker01", "interfaces.0.network_name", fmt.Sprintf("terraform-%d-1", rInt1)),
-					resource.TestCheckResourceAttr("cloudscale_server.web-worker01", "interfaces.0.addresses.#", "1"),
-					resource.TestCheckResourceAttr("cloudscale_server.web-worker01", "interfaces.0.addresses.0.address", "10.2.1.124"),
-					resource.TestCheckResourceAttr("cloudscale_server.web-worker01", "interfaces.0.addresses.0.subnet_cidr", "10.2.1.0/24"),
+					resource.TestCheckTypeSetElemNestedAttrs("cloudscale_server.web-worker01", "interfaces.*", map[string]string{
+						"type":                    "private",
+						"network_name":            fmt.Sprintf("terraform-%d-1", rInt1),
+						"addresses.#":             "1",
+						"addresses.0.address":     "10.2.1.124",
+						"addresses.0.subnet_cidr": "10.2.1.0/24",
+					}),
 				),
 			},
 			{
@@ -313,11 +398,15 @@ func TestAccCloudscaleSubnet_ServerAndMultipleSubnets(t *testing.T) {
 					testAccCheckCloudscaleServerExists("cloudscale_server.web-worker01", &server),
 					testAccCheckCloudscaleAddressOnSubnet(&server, &subnets[0], 0, 0),
 					resource.TestCheckResourceAttr("cloudscale_server.web-worker01", "interfaces.#", "1"),
-					resource.TestCheckResourceAttr("cloudscale_server.web-worker01", "interfaces.0.type", "private"),
-					resource.TestCheckResourceAttr("cloudscale_server.web-worker01", "interfaces.0.network_name", fmt.Sprintf("terraform-%d-0", rInt1)),
-					resource.TestCheckResourceAttr("cloudscale_server.web-worker01", "interfaces.0.addresses.#", "1"),
-					resource.TestCheckResourceAttrSet("cloudscale_server.web-worker01", "interfaces.0.addresses.0.address"),
-					resource.TestCheckResourceAttr("cloudscale_server.web-worker01", "interfaces.0.addresses.0.subnet_cidr", "10.2.0.0/24"),
+					resource.TestCheckTypeSetElemNestedAttrs("cloudscale_server.web-worker01", "interfaces.*", map[string]string{
+						"type":                    "private",
+						"network_name":            fmt.Sprintf("terraform-%d-0", rInt1),
+						"addresses.#":             "1",
+						"addresses.0.subnet_cidr": "10.2.0.0/24",
+					}),
+					resource.TestMatchTypeSetElemNestedAttrs("cloudscale_server.web-worker01", "interfaces.*", map[string]*regexp.Regexp{
+						"addresses.0.address": regexp.MustCompile(`.+`),
+					}),
 				),
 			},
 			{
@@ -333,11 +422,13 @@ func TestAccCloudscaleSubnet_ServerAndMultipleSubnets(t *testing.T) {
 					testAccCheckCloudscaleServerExists("cloudscale_server.web-worker01", &server),
 					testAccCheckCloudscaleAddressOnSubnet(&server, &subnets[0], 0, 0),
 					resource.TestCheckResourceAttr("cloudscale_server.web-worker01", "interfaces.#", "1"),
-					resource.TestCheckResourceAttr("cloudscale_server.web-worker01", "interfaces.0.type", "private"),
-					resource.TestCheckResourceAttr("cloudscale_server.web-worker01", "interfaces.0.network_name", fmt.Sprintf("terraform-%d-0", rInt1)),
-					resource.TestCheckResourceAttr("cloudscale_server.web-worker01", "interfaces.0.addresses.#", "1"),
-					resource.TestCheckResourceAttr("cloudscale_server.web-worker01", "interfaces.0.addresses.0.address", "10.2.0.124"),
-					resource.TestCheckResourceAttr("cloudscale_server.web-worker01", "interfaces.0.addresses.0.subnet_cidr", "10.2.0.0/24"),
+					resource.TestCheckTypeSetElemNestedAttrs("cloudscale_server.web-worker01", "interfaces.*", map[string]string{
+						"type":                    "private",
+						"network_name":            fmt.Sprintf("terraform-%d-0", rInt1),
+						"addresses.#":             "1",
+						"addresses.0.address":     "10.2.0.124",
+						"addresses.0.subnet_cidr": "10.2.0.0/24",
+					}),
 				),
 			},
 			{
@@ -353,11 +444,13 @@ func TestAccCloudscaleSubnet_ServerAndMultipleSubnets(t *testing.T) {
 					testAccCheckCloudscaleServerExists("cloudscale_server.web-worker01", &server),
 					testAccCheckCloudscaleAddressOnSubnet(&server, &subnets[1], 0, 0),
 					resource.TestCheckResourceAttr("cloudscale_server.web-worker01", "interfaces.#", "1"),
-					resource.TestCheckResourceAttr("cloudscale_server.web-worker01", "interfaces.0.type", "private"),
-					resource.TestCheckResourceAttr("cloudscale_server.web-worker01", "interfaces.0.network_name", fmt.Sprintf("terraform-%d-1", rInt1)),
-					resource.TestCheckResourceAttr("cloudscale_server.web-worker01", "interfaces.0.addresses.#", "1"),
-					resource.TestCheckResourceAttr("cloudscale_server.web-worker01", "interfaces.0.addresses.0.address", "10.2.1.124"),
-					resource.TestCheckResourceAttr("cloudscale_server.web-worker01", "interfaces.0.addresses.0.subnet_cidr", "10.2.1.0/24"),
+					resource.TestCheckTypeSetElemNestedAttrs("cloudscale_server.web-worker01", "interfaces.*", map[string]string{
+						"type":                    "private",
+						"network_name":            fmt.Sprintf("terraform-%d-1", rInt1),
+						"addresses.#":             "1",
+						"addresses.0.address":     "10.2.1.124",
+						"addresses.0.subnet_cidr": "10.2.1.0/24",
+					}),
 				),
 			},
 		},
@@ -484,7 +577,7 @@ func testAccCheckCloudscaleAddressOnSubnet(server *cloudscale.Server, subnet *cl
 }
 
 func testAccCheckCloudscaleSubnetDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*cloudscale.Client)
+	client := testAccProvider.Meta().(*providerMeta).Client
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "cloudscale_subnet" {
@@ -519,6 +612,16 @@ resource "cloudscale_subnet" "basic" {
 `)
 }
 
+func subnetconfigValidation(rInt int, cidr string, extra string) string {
+	return networkconfigMinimal(rInt, false) + fmt.Sprintf(`
+resource "cloudscale_subnet" "basic" {
+  cidr            = "%s"
+  network_uuid    = cloudscale_network.basic.id
+  %s
+}
+`, cidr, extra)
+}
+
 func subnetconfigMinimalWithTags(rInt int) string {
 	return networkconfigMinimal(rInt, false) + fmt.Sprintf(`
 resource "cloudscale_subnet" "basic" {
@@ -105,7 +105,7 @@ func getVolumeSchema(t SchemaType) map[string]*schema.Schema {
 }
 
 func createVolume(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 
 	opts := &cloudscale.VolumeCreateRequest{
 		Name: d.Get("name").(string),
@@ -189,12 +189,12 @@ func gatherVolumeResourceData(volume *cloudscale.Volume) ResourceDataRaw {
 }
 
 func readVolume(ctx context.Context, rId GenericResourceIdentifier, meta any) (*cloudscale.Volume, error) {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.Volumes.Get(ctx, rId.Id)
 }
 
 func updateVolume(ctx context.Context, rId GenericResourceIdentifier, meta any, updateRequest *cloudscale.VolumeUpdateRequest) error {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.Volumes.Update(ctx, rId.Id, updateRequest)
 }
 
@@ -228,6 +228,6 @@ func gatherVolumeUpdateRequests(d *schema.ResourceData) []*cloudscale.VolumeUpda
 }
 
 func deleteVolume(ctx context.Context, rId GenericResourceIdentifier, meta any) error {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.Volumes.Delete(ctx, rId.Id)
 }
@@ -96,7 +96,7 @@ func createVolumeSnapshot(ctx context.Context, d *schema.ResourceData, meta inte
 	timeout := d.Timeout(schema.TimeoutCreate)
 	startTime := time.Now()
 
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 
 	sourceVolumeUUID := d.Get("source_volume_uuid").(string)
 
@@ -127,7 +127,7 @@ func createVolumeSnapshot(ctx context.Context, d *schema.ResourceData, meta inte
 }
 
 func newVolumeSnapshotRefreshFunc(ctx context.Context, d *schema.ResourceData, attribute string, meta any) resource.StateRefreshFunc {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return func() (any, string, error) {
 		id := d.Id()
 
@@ -163,12 +163,12 @@ func gatherVolumeSnapshotResourceData(snap *cloudscale.VolumeSnapshot) ResourceD
 }
 
 func readVolumeSnapshot(ctx context.Context, rId GenericResourceIdentifier, meta any) (*cloudscale.VolumeSnapshot, error) {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.VolumeSnapshots.Get(ctx, rId.Id)
 }
 
 func updateVolumeSnapshot(ctx context.Context, rId GenericResourceIdentifier, meta any, updateRequest *cloudscale.VolumeSnapshotUpdateRequest) error {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	return client.VolumeSnapshots.Update(ctx, rId.Id, updateRequest)
 }
 
@@ -192,7 +192,7 @@ func gatherVolumeSnapshotUpdateRequest(d *schema.ResourceData) []*cloudscale.Vol
 }
 
 func deleteVolumeSnapshot(ctx context.Context, rId GenericResourceIdentifier, meta any) error {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 
 	if err := client.VolumeSnapshots.Delete(ctx, rId.Id); err != nil {
 		return err
@@ -204,7 +204,7 @@ func deleteVolumeSnapshot(ctx context.Context, rId GenericResourceIdentifier, me
 }
 
 func waitForVolumeSnapshotDeleted(ctx context.Context, id string, meta any) error {
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 	err := waitForDeleted(ctx, func() (exists bool, err error) {
 		snapshot, err := client.VolumeSnapshots.Get(ctx, id)
 		if err != nil {
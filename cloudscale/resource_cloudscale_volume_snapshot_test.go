@@ -29,7 +29,7 @@ func testSweepVolumeSnapshots(region string) error {
 		return err
 	}
 
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 
 	snapshots, err := client.VolumeSnapshots.List(context.Background())
 	if err != nil {
@@ -258,7 +258,7 @@ func TestAccCloudscaleVolumeSnapshot_tags(t *testing.T) {
 }
 
 func testAccCheckCloudscaleVolumeSnapshotDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*cloudscale.Client)
+	client := testAccProvider.Meta().(*providerMeta).Client
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "cloudscale_volume_snapshot" {
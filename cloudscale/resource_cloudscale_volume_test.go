@@ -30,7 +30,7 @@ func testSweepVolumes(region string) error {
 		return err
 	}
 
-	client := meta.(*cloudscale.Client)
+	client := meta.(*providerMeta).Client
 
 	volumes, err := client.Volumes.List(context.Background())
 	if err != nil {
@@ -311,7 +311,7 @@ func testAccCheckCloudscaleVolumeIsSame(t *testing.T,
 }
 
 func testAccCheckCloudscaleVolumeDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*cloudscale.Client)
+	client := testAccProvider.Meta().(*providerMeta).Client
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "cloudscale_volume" {
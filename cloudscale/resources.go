@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -141,6 +142,14 @@ func uuidLockKey(attr string, keyFunc func(string) string) mutexKeyFunc {
 	}
 }
 
+// validateDuration accepts durations as understood by time.ParseDuration.
+func validateDuration(v any, k string) ([]string, []error) {
+	if _, err := time.ParseDuration(v.(string)); err != nil {
+		return nil, []error{fmt.Errorf("%q must be a duration such as \"2m\": %s", k, err)}
+	}
+	return nil, nil
+}
+
 type GenericResourceIdentifier struct {
 	Id string
 }
@@ -0,0 +1,82 @@
+package cloudscale
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+)
+
+// generatedPasswordLength and generatedPasswordAlphabet define the passwords
+// created for generate_password. The alphabet leaves out characters that are
+// awkward to type on a console or to quote in a shell.
+const (
+	generatedPasswordLength   = 32
+	generatedPasswordAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_.+=%"
+)
+
+// generateServerPassword returns a random password drawn from a
+// cryptographically secure source.
+func generateServerPassword() (string, error) {
+	alphabetSize := big.NewInt(int64(len(generatedPasswordAlphabet)))
+	password := make([]byte, generatedPasswordLength)
+	for i := range password {
+		n, err := rand.Int(rand.Reader, alphabetSize)
+		if err != nil {
+			return "", fmt.Errorf("error generating password: %s", err)
+		}
+		password[i] = generatedPasswordAlphabet[n.Int64()]
+	}
+	return string(password), nil
+}
+
+// readPGPPublicKey parses a public key given either ASCII armored or as
+// base64-encoded binary (the output of `gpg --export <id> | base64`).
+func readPGPPublicKey(key string) (*openpgp.Entity, error) {
+	var entities openpgp.EntityList
+	var err error
+	if strings.Contains(key, "-----BEGIN PGP PUBLIC KEY BLOCK-----") {
+		entities, err = openpgp.ReadArmoredKeyRing(strings.NewReader(key))
+	} else {
+		var binaryKey []byte
+		binaryKey, err = base64.StdEncoding.DecodeString(strings.TrimSpace(key))
+		if err != nil {
+			return nil, fmt.Errorf("pgp_key is neither ASCII armored nor base64 encoded: %s", err)
+		}
+		entities, err = openpgp.ReadKeyRing(bytes.NewReader(binaryKey))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading pgp_key: %s", err)
+	}
+	if len(entities) != 1 {
+		return nil, fmt.Errorf("pgp_key must contain exactly one public key, found %d", len(entities))
+	}
+	return entities[0], nil
+}
+
+// encryptPassword encrypts password for the holder of key. The result is the
+// base64-encoded binary message, to be decrypted with
+// `base64 -d | gpg --decrypt`.
+func encryptPassword(password string, key string) (string, error) {
+	entity, err := readPGPPublicKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	w, err := openpgp.Encrypt(&buf, []*openpgp.Entity{entity}, nil, nil, nil)
+	if err != nil {
+		return "", fmt.Errorf("error encrypting password: %s", err)
+	}
+	if _, err := w.Write([]byte(password)); err != nil {
+		return "", fmt.Errorf("error encrypting password: %s", err)
+	}
+	if err := w.Close(); err != nil {
+		return "", fmt.Errorf("error encrypting password: %s", err)
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
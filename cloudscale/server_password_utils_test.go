@@ -0,0 +1,100 @@
+package cloudscale
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+)
+
+// testPGPEntity generates a throwaway key pair and returns it together with
+// its public key, both ASCII armored and base64 encoded.
+func testPGPEntity(t *testing.T) (*openpgp.Entity, string, string) {
+	t.Helper()
+
+	entity, err := openpgp.NewEntity("terraform", "test", "terraform@example.com", nil)
+	if err != nil {
+		t.Fatalf("generating key: %s", err)
+	}
+
+	var binaryKey bytes.Buffer
+	if err := entity.Serialize(&binaryKey); err != nil {
+		t.Fatalf("serializing key: %s", err)
+	}
+
+	var armoredKey bytes.Buffer
+	w, err := armor.Encode(&armoredKey, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatalf("armoring key: %s", err)
+	}
+	if _, err := w.Write(binaryKey.Bytes()); err != nil {
+		t.Fatalf("armoring key: %s", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("armoring key: %s", err)
+	}
+
+	return entity, armoredKey.String(), base64.StdEncoding.EncodeToString(binaryKey.Bytes())
+}
+
+func TestGenerateServerPassword(t *testing.T) {
+	first, err := generateServerPassword()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	second, err := generateServerPassword()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(first) != generatedPasswordLength {
+		t.Errorf("len = %d, want %d", len(first), generatedPasswordLength)
+	}
+	for _, c := range first {
+		if !strings.ContainsRune(generatedPasswordAlphabet, c) {
+			t.Errorf("unexpected character %q", c)
+		}
+	}
+	if first == second {
+		t.Error("two generated passwords are equal")
+	}
+}
+
+func TestEncryptPassword(t *testing.T) {
+	entity, armoredKey, base64Key := testPGPEntity(t)
+
+	for name, key := range map[string]string{"armored": armoredKey, "base64": base64Key} {
+		t.Run(name, func(t *testing.T) {
+			encrypted, err := encryptPassword("rivella17", key)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			message, err := base64.StdEncoding.DecodeString(encrypted)
+			if err != nil {
+				t.Fatalf("decoding base64: %s", err)
+			}
+			md, err := openpgp.ReadMessage(bytes.NewReader(message), openpgp.EntityList{entity}, nil, nil)
+			if err != nil {
+				t.Fatalf("decrypting: %s", err)
+			}
+			decrypted, err := io.ReadAll(md.UnverifiedBody)
+			if err != nil {
+				t.Fatalf("reading decrypted message: %s", err)
+			}
+			if string(decrypted) != "rivella17" {
+				t.Errorf("decrypted = %q, want %q", decrypted, "rivella17")
+			}
+		})
+	}
+}
+
+func TestEncryptPassword_InvalidKey(t *testing.T) {
+	if _, err := encryptPassword("rivella17", "not a key"); err == nil {
+		t.Error("expected an error for an invalid key")
+	}
+}
@@ -0,0 +1,79 @@
+package cloudscale
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/cloudscale-ch/cloudscale-go-sdk/v10"
+)
+
+// The SDK's ServerUpdateRequest can't carry reverse pointers, so they are set
+// with a PATCH of our own. Changing the addresses of a server means sending all
+// of its interfaces, hence the request mirrors the current ones.
+
+type serverReversePtrUpdateRequest struct {
+	Interfaces []serverReversePtrInterfaceRequest `json:"interfaces"`
+}
+
+type serverReversePtrInterfaceRequest struct {
+	Network   string                            `json:"network,omitempty"`
+	Addresses *[]serverReversePtrAddressRequest `json:"addresses,omitempty"`
+}
+
+type serverReversePtrAddressRequest struct {
+	Address    string `json:"address"`
+	Subnet     string `json:"subnet,omitempty"`
+	ReversePtr string `json:"reverse_ptr,omitempty"`
+}
+
+// newServerReversePtrUpdateRequest keeps all interfaces and addresses of the
+// server as they are and sets the reverse pointer of its first public IPv4
+// and IPv6 address. An empty pointer leaves the current one in place, so a
+// pointer removed from the configuration is not reset to the default.
+func newServerReversePtrUpdateRequest(server *cloudscale.Server, ipv4ReversePtr string, ipv6ReversePtr string) *serverReversePtrUpdateRequest {
+	wanted := map[int]string{4: ipv4ReversePtr, 6: ipv6ReversePtr}
+
+	result := &serverReversePtrUpdateRequest{
+		Interfaces: make([]serverReversePtrInterfaceRequest, 0, len(server.Interfaces)),
+	}
+	for _, intr := range server.Interfaces {
+		addresses := make([]serverReversePtrAddressRequest, 0, len(intr.Addresses))
+		for _, addr := range intr.Addresses {
+			address := serverReversePtrAddressRequest{Address: addr.Address}
+			if intr.Type == "public" {
+				address.ReversePtr = addr.ReversePtr
+				if ptr := wanted[addr.Version]; ptr != "" {
+					address.ReversePtr = ptr
+					// Only the first address of each version is managed.
+					delete(wanted, addr.Version)
+				}
+			} else {
+				address.Subnet = addr.Subnet.UUID
+			}
+			addresses = append(addresses, address)
+		}
+
+		interfaceRequest := serverReversePtrInterfaceRequest{Addresses: &addresses}
+		if intr.Type == "public" {
+			interfaceRequest.Network = "public"
+		} else if len(addresses) == 0 {
+			// Without addresses, the network can't be derived from the subnets.
+			interfaceRequest.Network = intr.Network.UUID
+		}
+		result.Interfaces = append(result.Interfaces, interfaceRequest)
+	}
+	return result
+}
+
+func updateServerReversePtrs(ctx context.Context, client *cloudscale.Client, id string, ipv4ReversePtr string, ipv6ReversePtr string) error {
+	server, err := client.Servers.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	req, err := client.NewRequest(ctx, http.MethodPatch, "v1/servers/"+id, newServerReversePtrUpdateRequest(server, ipv4ReversePtr, ipv6ReversePtr))
+	if err != nil {
+		return err
+	}
+	return client.Do(ctx, req, nil)
+}
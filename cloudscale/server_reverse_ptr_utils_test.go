@@ -0,0 +1,88 @@
+package cloudscale
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+const testServerReversePtrUUID = "9ab2b2ee-2e84-4d7f-a5a7-a2c9e8e8d4b6"
+
+const testServerReversePtrJSON = `{
+	"uuid": "9ab2b2ee-2e84-4d7f-a5a7-a2c9e8e8d4b6",
+	"interfaces": [
+		{
+			"type": "public",
+			"network": {"uuid": "public-net"},
+			"addresses": [
+				{"version": 4, "address": "192.0.2.10", "reverse_ptr": "192-0-2-10.cust.cloudscale.ch"},
+				{"version": 6, "address": "2001:db8::10", "reverse_ptr": "old.example.com"}
+			]
+		},
+		{
+			"type": "private",
+			"network": {"uuid": "net-a"},
+			"addresses": [
+				{"version": 4, "address": "10.0.0.5", "subnet": {"uuid": "subnet-a"}}
+			]
+		},
+		{
+			"type": "private",
+			"network": {"uuid": "net-b"},
+			"addresses": []
+		}
+	]
+}`
+
+func TestUpdateServerReversePtrs(t *testing.T) {
+	var body []byte
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/servers/"+testServerReversePtrUUID, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = io.WriteString(w, testServerReversePtrJSON)
+		case http.MethodPatch:
+			var err error
+			body, err = io.ReadAll(r.Body)
+			if err != nil {
+				t.Errorf("reading request body: %s", err)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected %s request", r.Method)
+		}
+	})
+	client := testClient(t, mux)
+
+	err := updateServerReversePtrs(context.Background(), client.Client, testServerReversePtrUUID, "mail.example.com", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var actual any
+	if err := json.Unmarshal(body, &actual); err != nil {
+		t.Fatalf("decoding request body %q: %s", body, err)
+	}
+	var expected any
+	err = json.Unmarshal([]byte(`{"interfaces": [
+		{
+			"network": "public",
+			"addresses": [
+				{"address": "192.0.2.10", "reverse_ptr": "mail.example.com"},
+				{"address": "2001:db8::10", "reverse_ptr": "old.example.com"}
+			]
+		},
+		{"addresses": [{"address": "10.0.0.5", "subnet": "subnet-a"}]},
+		{"network": "net-b", "addresses": []}
+	]}`), &expected)
+	if err != nil {
+		t.Fatalf("decoding expected body: %s", err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("request body = %s", body)
+	}
+}
@@ -0,0 +1,167 @@
+package cloudscale
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/cloudscale-ch/cloudscale-go-sdk/v10"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// slugSource describes an API endpoint listing the valid values of a slug
+// attribute.
+type slugSource struct {
+	humanName string
+	path      string
+	fetch     func(ctx context.Context, client *cloudscale.Client, path string) ([]string, error)
+}
+
+var (
+	flavorSlugs             = slugSource{"flavor", "v1/flavors", fetchSlugs}
+	imageSlugs              = slugSource{"image", "v1/images", fetchSlugs}
+	zoneSlugs               = slugSource{"zone", "v1/regions", fetchZoneSlugs}
+	loadBalancerFlavorSlugs = slugSource{"load balancer flavor", "v1/load-balancers/flavors", fetchSlugs}
+)
+
+// cachedSlugs returns the slugs listed by source. Flavors, images and zones
+// rarely change, so they are fetched at most once per provider instance.
+func cachedSlugs(ctx context.Context, meta *providerMeta, source slugSource) ([]string, error) {
+	slugs, err := meta.slugs.get(ctx, source.path, func(ctx context.Context) ([]string, error) {
+		return source.fetch(ctx, meta.Client, source.path)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing %ss: %s", source.humanName, err)
+	}
+	return slugs, nil
+}
+
+func fetchSlugs(ctx context.Context, client *cloudscale.Client, path string) ([]string, error) {
+	var objects []struct {
+		Slug string `json:"slug"`
+	}
+	if err := getFromAPI(ctx, client, path, &objects); err != nil {
+		return nil, err
+	}
+	slugs := make([]string, len(objects))
+	for i, object := range objects {
+		slugs[i] = object.Slug
+	}
+	return slugs, nil
+}
+
+func fetchZoneSlugs(ctx context.Context, client *cloudscale.Client, path string) ([]string, error) {
+	var regions []struct {
+		Zones []struct {
+			Slug string `json:"slug"`
+		} `json:"zones"`
+	}
+	if err := getFromAPI(ctx, client, path, &regions); err != nil {
+		return nil, err
+	}
+	var slugs []string
+	for _, region := range regions {
+		for _, zone := range region.Zones {
+			slugs = append(slugs, zone.Slug)
+		}
+	}
+	return slugs, nil
+}
+
+func getFromAPI(ctx context.Context, client *cloudscale.Client, path string, v any) error {
+	req, err := client.NewRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	return client.Do(ctx, req, v)
+}
+
+// validateSlug fails the plan if the new value of key is not one of the slugs
+// listed by source. Only new and changed values are checked, so resources
+// using a slug that has since been retired keep working.
+func validateSlug(ctx context.Context, d *schema.ResourceDiff, meta *providerMeta, key string, source slugSource) error {
+	if !d.HasChange(key) || !d.NewValueKnown(key) {
+		return nil
+	}
+	slug := d.Get(key).(string)
+	if slug == "" {
+		return nil
+	}
+
+	slugs, err := cachedSlugs(ctx, meta, source)
+	if err != nil {
+		return err
+	}
+	for _, s := range slugs {
+		if s == slug {
+			return nil
+		}
+	}
+
+	if suggestion := closestSlug(slug, slugs); suggestion != "" {
+		return fmt.Errorf("%s: unknown %s %q, did you mean %q?", key, source.humanName, slug, suggestion)
+	}
+	sorted := append([]string(nil), slugs...)
+	sort.Strings(sorted)
+	return fmt.Errorf("%s: unknown %s %q, expected one of: %s", key, source.humanName, slug, strings.Join(sorted, ", "))
+}
+
+// closestSlug returns the candidate most similar to slug, or "" if none is
+// close enough to be a plausible typo.
+func closestSlug(slug string, candidates []string) string {
+	best := ""
+	bestDistance := 0
+	for _, candidate := range candidates {
+		distance := levenshteinDistance(slug, candidate)
+		if best == "" || distance < bestDistance || (distance == bestDistance && candidate < best) {
+			best = candidate
+			bestDistance = distance
+		}
+	}
+	if best == "" || bestDistance > len(slug)/3+1 {
+		return ""
+	}
+	return best
+}
+
+func levenshteinDistance(a string, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+func validateServerSlugs(ctx context.Context, d *schema.ResourceDiff, meta any) error {
+	m := meta.(*providerMeta)
+
+	if err := validateSlug(ctx, d, m, "flavor_slug", flavorSlugs); err != nil {
+		return err
+	}
+	// Custom images are referenced as "custom:<slug>" and aren't listed with
+	// the public images.
+	if !strings.HasPrefix(d.Get("image_slug").(string), "custom:") {
+		if err := validateSlug(ctx, d, m, "image_slug", imageSlugs); err != nil {
+			return err
+		}
+	}
+	return validateSlug(ctx, d, m, "zone_slug", zoneSlugs)
+}
+
+func validateLoadBalancerSlugs(ctx context.Context, d *schema.ResourceDiff, meta any) error {
+	return validateSlug(ctx, d, meta.(*providerMeta), "flavor_slug", loadBalancerFlavorSlugs)
+}
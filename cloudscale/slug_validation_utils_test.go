@@ -0,0 +1,90 @@
+package cloudscale
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestClosestSlug(t *testing.T) {
+	candidates := []string{"flex-4-1", "flex-4-2", "flex-8-2", "plus-4-2"}
+
+	for slug, expected := range map[string]string{
+		"flex4-1":   "flex-4-1",
+		"flex-4-3":  "flex-4-1",
+		"plsu-4-2":  "plus-4-2",
+		"gpu-24-96": "",
+	} {
+		if actual := closestSlug(slug, candidates); actual != expected {
+			t.Errorf("closestSlug(%q) = %q, want %q", slug, actual, expected)
+		}
+	}
+
+	if actual := closestSlug("flex-4-1", nil); actual != "" {
+		t.Errorf("closestSlug without candidates = %q, want \"\"", actual)
+	}
+}
+
+func TestCachedSlugs(t *testing.T) {
+	requests := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/regions", func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `[
+			{"slug": "lpg", "zones": [{"slug": "lpg1"}]},
+			{"slug": "rma", "zones": [{"slug": "rma1"}]}
+		]`)
+	})
+	client := testClient(t, mux)
+
+	for i := 0; i < 2; i++ {
+		slugs, err := cachedSlugs(context.Background(), client, zoneSlugs)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if expected := []string{"lpg1", "rma1"}; !reflect.DeepEqual(slugs, expected) {
+			t.Errorf("got %v, want %v", slugs, expected)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("got %d requests, want 1", requests)
+	}
+
+	// Another provider instance has its own cache.
+	if _, err := cachedSlugs(context.Background(), testClient(t, mux), zoneSlugs); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if requests != 2 {
+		t.Errorf("got %d requests, want 2", requests)
+	}
+}
+
+func TestCachedSlugs_Error(t *testing.T) {
+	requests := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/flavors", func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `[{"slug": "flex-4-1"}]`)
+	})
+	client := testClient(t, mux)
+
+	if _, err := cachedSlugs(context.Background(), client, flavorSlugs); err == nil {
+		t.Fatal("expected an error")
+	}
+	// Failed lookups are not cached.
+	slugs, err := cachedSlugs(context.Background(), client, flavorSlugs)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if expected := []string{"flex-4-1"}; !reflect.DeepEqual(slugs, expected) {
+		t.Errorf("got %v, want %v", slugs, expected)
+	}
+}
@@ -27,5 +27,5 @@ func sharedConfigForRegion(region string) (any, error) {
 		return nil, fmt.Errorf("error getting cloudscale client")
 	}
 
-	return client, nil
+	return newProviderMeta(client), nil
 }
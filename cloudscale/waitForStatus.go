@@ -8,6 +8,27 @@ import (
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 )
 
+// The polling intervals are variables, so that unit tests don't have to wait.
+var (
+	waitForStatusDelay      = 10 * time.Second
+	waitForStatusMinTimeout = 3 * time.Second
+	waitForDeletedInterval  = 10 * time.Second
+)
+
+// deadlineReserve is the time capToDeadline keeps free before the deadline of
+// an operation, for the requests that follow a wait that may end early.
+var deadlineReserve = 2 * time.Minute
+
+// capToDeadline shortens timeout, so that waiting for it ends deadlineReserve
+// before the deadline of ctx, e.g. the delete timeout. The result is not
+// positive when there is no time left to wait.
+func capToDeadline(ctx context.Context, timeout time.Duration) time.Duration {
+	if deadline, ok := ctx.Deadline(); ok {
+		return min(timeout, time.Until(deadline)-deadlineReserve)
+	}
+	return timeout
+}
+
 func waitForStatus(
 	ctx context.Context,
 	pending []string,
@@ -25,8 +46,8 @@ func waitForStatus(
 		Target:         []string{target},
 		Refresh:        refreshFunc,
 		Timeout:        *timeout,
-		Delay:          10 * time.Second,
-		MinTimeout:     3 * time.Second,
+		Delay:          waitForStatusDelay,
+		MinTimeout:     waitForStatusMinTimeout,
 		NotFoundChecks: math.MaxInt32,
 	}
 
@@ -39,7 +60,7 @@ func waitForStatus(
 // existsFunc must return (true, nil) while the resource exists,
 // (false, nil) once it is gone, or (_, err) on unexpected errors.
 func waitForDeleted(ctx context.Context, existsFunc func() (bool, error)) error {
-	time.Sleep(10 * time.Second)
+	time.Sleep(waitForDeletedInterval)
 	for {
 		if err := ctx.Err(); err != nil {
 			return err
@@ -51,6 +72,6 @@ func waitForDeleted(ctx context.Context, existsFunc func() (bool, error)) error
 		if !exists {
 			return nil
 		}
-		time.Sleep(10 * time.Second)
+		time.Sleep(waitForDeletedInterval)
 	}
 }